@@ -0,0 +1,178 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sabloger/sitemap-generator/smg"
+	"github.com/sabloger/sitemap-generator/source"
+)
+
+// runGenerate reads the URLs of input files and saves them as a sitemap or sitemap index.
+func runGenerate(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, "Usage: smg generate [flags] [files...]\n\n"+
+			"Reads URLs from the files or stdin in case of no files and writes the sitemap files.\n\n")
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "", "input format: csv, tsv, jsonl or text (default is detected by file extension)")
+	columnsFlag := flags.String("columns", "", "columns mapping of input, e.g. loc=url,lastmod=updated_at,changefreq=freq,priority=prio,images=pictures")
+	imagesSeparator := flags.String("images-separator", "|", "separator of image URLs in csv and tsv columns")
+	hostname := flags.String("hostname", "", "hostname which is prepended to all URLs, e.g. https://www.example.com")
+	outputPath := flags.String("output", ".", "output directory path")
+	name := flags.String("name", "sitemap", "name of sitemap or sitemap index file without extension")
+	index := flags.Bool("index", false, "write a sitemap index which references the sitemap files")
+	serverURI := flags.String("server-uri", "", "path of sitemap files on the server which is used in sitemap index")
+	compress := flags.Bool("compress", true, "gzip compress the output files")
+	gzipLevel := flags.Int("gzip-level", -1, "gzip level of compressed files from 1 to 9, -1 is the default level")
+	bothVariants := flags.Bool("both-variants", false, "write both the uncompressed and compressed files, -compress designates the referenced one")
+	maxURLs := flags.Int("max-urls", 50000, "maximum number of URLs in each sitemap file")
+	maxBytes := flags.Int("max-bytes", 52428800, "maximum uncompressed size of each sitemap file in bytes")
+	maxGzipBytes := flags.Int("max-gzip-bytes", 0, "maximum compressed size of each sitemap file in bytes, 0 disables it")
+	prettyPrint := flags.Bool("pretty", false, "pretty print the xml output")
+	hashFilenames := flags.Bool("hash-filenames", false, "append the content hash to the sitemap filenames")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	columns, err := parseColumns(*columnsFlag)
+	if err != nil {
+		fmt.Fprintln(stderr, "smg generate:", err)
+		return 2
+	}
+	columns.ImagesSeparator = *imagesSeparator
+
+	var smi *smg.SitemapIndex
+	var sm *smg.Sitemap
+	if *index {
+		smi = smg.NewSitemapIndex(*prettyPrint)
+		smi.SetSitemapIndexName(*name)
+		smi.SetHostname(*hostname)
+		smi.SetOutputPath(*outputPath)
+		smi.SetServerURI(*serverURI)
+		smi.SetCompress(*compress)
+		smi.SetWriteBothVariants(*bothVariants)
+		err = smi.SetCompressionLevel(*gzipLevel)
+		sm = smi.NewSitemap()
+	} else {
+		sm = smg.NewSitemap(*prettyPrint)
+		sm.SetName(*name)
+		sm.SetHostname(*hostname)
+		sm.SetOutputPath(*outputPath)
+		sm.SetServerURI(*serverURI)
+		sm.SetCompress(*compress)
+		sm.SetWriteBothVariants(*bothVariants)
+		err = sm.SetCompressionLevel(*gzipLevel)
+	}
+	sm.SetMaxURLsCount(*maxURLs)
+	if err == nil && (*maxURLs < 1 || *maxURLs > smg.MaxURLsCount) {
+		err = fmt.Errorf("invalid -max-urls %d", *maxURLs)
+	}
+	if err == nil {
+		err = sm.SetMaxFileSize(*maxBytes)
+	}
+	if err == nil {
+		err = sm.SetMaxCompressedSize(*maxGzipBytes)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "smg generate:", err)
+		return 2
+	}
+	sm.SetHashFilenames(*hashFilenames)
+
+	inputs := flags.Args()
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+	for _, input := range inputs {
+		err = readInput(input, *format, columns, stdin, sm)
+		if err != nil {
+			fmt.Fprintf(stderr, "smg generate: %s: %s\n", input, err)
+			return 1
+		}
+	}
+
+	var filenames []string
+	if smi != nil {
+		var filename string
+		filename, err = smi.Save()
+		filenames = []string{filename}
+	} else {
+		filenames, err = sm.Save()
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "smg generate:", err)
+		return 1
+	}
+	for _, filename := range filenames {
+		fmt.Fprintln(stdout, filename)
+	}
+	return 0
+}
+
+// readInput reads the URLs of input file, or stdin in case of "-", into the sink.
+func readInput(input, formatName string, columns source.Columns, stdin io.Reader, sink source.Sink) error {
+	var format source.FileFormat
+	var err error
+	switch {
+	case formatName != "":
+		format, err = source.ParseFileFormat(formatName)
+	case input == "-":
+		err = fmt.Errorf("-format is required for reading stdin")
+	default:
+		format, err = source.FileFormatOf(input)
+	}
+	if err != nil {
+		return err
+	}
+
+	r := stdin
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	return source.ReadFile(r, format, columns, sink)
+}
+
+// parseColumns parses the columns mapping flag which is a comma separated
+// list of field=column pairs and overrides the default columns.
+func parseColumns(value string) (source.Columns, error) {
+	columns := source.DefaultColumns
+	if value == "" {
+		return columns, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return columns, fmt.Errorf("invalid columns mapping %q", pair)
+		}
+		field, column := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		switch strings.ToLower(field) {
+		case "loc":
+			columns.Loc = column
+		case "lastmod":
+			columns.LastMod = column
+		case "changefreq":
+			columns.ChangeFreq = column
+		case "priority":
+			columns.Priority = column
+		case "images":
+			columns.Images = column
+		default:
+			return columns, fmt.Errorf("unknown column field %q", field)
+		}
+	}
+	return columns, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGenerateSitemapIndex tests generating a sitemap index from a CSV file
+func TestGenerateSitemapIndex(t *testing.T) {
+	path := t.TempDir()
+	input := filepath.Join(path, "export.csv")
+	err := os.WriteFile(input, []byte("url,updated_at\n/a,2022-02-12\n/b,2022-02-13\n/c,\n"), 0666)
+	if err != nil {
+		t.Fatal("Unable to write input:", err)
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run([]string{"generate", "-index", "-hostname", "https://www.example.com",
+		"-output", path, "-name", "index", "-compress=false", "-max-urls", "2",
+		"-columns", "loc=url,lastmod=updated_at", input}, nil, stdout, stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, "index.xml\n", stdout.String())
+
+	for _, filename := range []string{"index.xml", "sitemap1.xml", "sitemap1-2.xml"} {
+		_, err = os.Stat(filepath.Join(path, filename))
+		assert.NoError(t, err)
+	}
+	content, err := os.ReadFile(filepath.Join(path, "sitemap1.xml"))
+	if err != nil {
+		t.Fatal("Unable to read sitemap:", err)
+	}
+	assert.Contains(t, string(content), "<loc>https://www.example.com/a</loc>")
+	assert.Contains(t, string(content), "<lastmod>2022-02-12T00:00:00Z</lastmod>")
+}
+
+// TestGenerateSitemapFromStdin tests generating a single sitemap from text input of stdin
+func TestGenerateSitemapFromStdin(t *testing.T) {
+	path := t.TempDir()
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run([]string{"generate", "-format", "text", "-hostname", "https://www.example.com", "-output", path},
+		strings.NewReader("/a\n/b\n"), stdout, stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, "sitemap.xml.gz\n", stdout.String())
+
+	code = run([]string{"generate", "-output", path}, strings.NewReader("/a\n"), stdout, stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "-format is required")
+
+	code = run([]string{"generate", "-columns", "title=name"}, nil, stdout, stderr)
+	assert.Equal(t, 2, code)
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sabloger/sitemap-generator/smg"
+	"github.com/sabloger/sitemap-generator/source"
+)
+
+// Limits of sitemaps.org protocol
+const (
+	maxLocLength  = 2048
+	severityError = "error"
+	severityWarn  = "warning"
+)
+
+// w3cLayouts are the valid W3C Datetime layouts of lastmod values.
+var w3cLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// issue is a protocol violation or a problem which is found while inspecting.
+type issue struct {
+	Severity string `json:"severity"`
+	File     string `json:"file"`
+	Loc      string `json:"loc,omitempty"`
+	Message  string `json:"message"`
+}
+
+// fileReport contains the stats of an inspected sitemap or sitemap index file.
+type fileReport struct {
+	Path             string `json:"path"`
+	Type             string `json:"type"`
+	Compressed       bool   `json:"compressed"`
+	Size             int64  `json:"size"`
+	UncompressedSize int64  `json:"uncompressed_size"`
+	URLCount         int    `json:"url_count"`
+	MinLastMod       string `json:"min_lastmod,omitempty"`
+	MaxLastMod       string `json:"max_lastmod,omitempty"`
+	minLastMod       time.Time
+	maxLastMod       time.Time
+}
+
+// inspection is the result of inspecting a sitemap or sitemap index with its sitemaps.
+type inspection struct {
+	Files          []*fileReport  `json:"files"`
+	Issues         []*issue       `json:"issues"`
+	URLCount       int            `json:"url_count"`
+	LastModByMonth map[string]int `json:"lastmod_by_month"`
+	URLsByHost     map[string]int `json:"urls_by_host"`
+	seen           map[string]string
+}
+
+// inspector loads the sitemap files and builds the inspection.
+// root and serverURI are used for mapping the loc of index entries to local files.
+type inspector struct {
+	root      string
+	serverURI string
+	result    *inspection
+	visited   map[string]bool
+}
+
+// urlEntry is a raw <url> or <sitemap> entry of the xml files.
+type urlEntry struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod"`
+	ChangeFreq string `xml:"changefreq"`
+	Priority   string `xml:"priority"`
+}
+
+// inspect loads the sitemap or sitemap index in filename and follows the index
+// entries which are mapped to local files under root.
+func inspect(filename, root, serverURI string) *inspection {
+	if root == "" {
+		root = filepath.Dir(filename)
+	}
+	in := &inspector{
+		root:      root,
+		serverURI: serverURI,
+		visited:   make(map[string]bool),
+		result: &inspection{
+			Files:          make([]*fileReport, 0),
+			Issues:         make([]*issue, 0),
+			LastModByMonth: make(map[string]int),
+			URLsByHost:     make(map[string]int),
+			seen:           make(map[string]string),
+		},
+	}
+	in.inspectFile(filename, true)
+	return in.result
+}
+
+// errorsCount returns the number of error issues.
+func (r *inspection) errorsCount() int {
+	n := 0
+	for _, i := range r.Issues {
+		if i.Severity == severityError {
+			n++
+		}
+	}
+	return n
+}
+
+func (in *inspector) addIssue(severity, file, loc, format string, args ...interface{}) {
+	in.result.Issues = append(in.result.Issues, &issue{
+		Severity: severity,
+		File:     file,
+		Loc:      loc,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// inspectFile loads a file and inspects it based on its type.
+// only the top-level file is allowed to be a sitemap index.
+func (in *inspector) inspectFile(filename string, allowIndex bool) {
+	in.visited[filename] = true
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		in.addIssue(severityError, filename, "", "%s", err)
+		return
+	}
+	report := &fileReport{
+		Path:             filename,
+		Size:             int64(len(raw)),
+		UncompressedSize: int64(len(raw)),
+	}
+	content := raw
+	if strings.HasSuffix(filename, ".gz") {
+		report.Compressed = true
+		content, err = smg.Gunzip(raw)
+		if err != nil {
+			in.addIssue(severityError, filename, "", "invalid gzip file: %s", err)
+			return
+		}
+		report.UncompressedSize = int64(len(content))
+	}
+	in.result.Files = append(in.result.Files, report)
+
+	if report.UncompressedSize > int64(smg.MaxFileSize) {
+		in.addIssue(severityError, filename, "", "uncompressed size %d exceeds %d bytes", report.UncompressedSize, smg.MaxFileSize)
+	}
+
+	if strings.HasSuffix(strings.TrimSuffix(filename, ".gz"), ".txt") {
+		report.Type = "text"
+		in.inspectText(report, content)
+	} else {
+		in.inspectXML(report, content, allowIndex)
+	}
+
+	if report.URLCount > smg.MaxURLsCount {
+		in.addIssue(severityError, filename, "", "%d entries exceed %d", report.URLCount, smg.MaxURLsCount)
+	}
+	if !report.minLastMod.IsZero() {
+		report.MinLastMod = report.minLastMod.Format(time.RFC3339)
+		report.MaxLastMod = report.maxLastMod.Format(time.RFC3339)
+	}
+}
+
+func (in *inspector) inspectText(report *fileReport, content []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		in.inspectURL(report, &urlEntry{Loc: line})
+	}
+	if err := scanner.Err(); err != nil {
+		in.addIssue(severityError, report.Path, "", "%s", err)
+	}
+}
+
+func (in *inspector) inspectXML(report *fileReport, content []byte, allowIndex bool) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	indexLocs := make([]*urlEntry, 0)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			in.addIssue(severityError, report.Path, "", "invalid xml: %s", err)
+			return
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "urlset":
+			report.Type = "urlset"
+		case "sitemapindex":
+			report.Type = "sitemapindex"
+			if !allowIndex {
+				in.addIssue(severityError, report.Path, "", "sitemap index must not be referenced by another sitemap index")
+				return
+			}
+		case "url", "sitemap":
+			var entry urlEntry
+			err = decoder.DecodeElement(&entry, &start)
+			if err != nil {
+				in.addIssue(severityError, report.Path, "", "invalid xml: %s", err)
+				return
+			}
+			if start.Name.Local == "sitemap" {
+				report.URLCount++
+				in.inspectLastMod(report, &entry)
+				indexLocs = append(indexLocs, &entry)
+			} else {
+				in.inspectURL(report, &entry)
+			}
+		}
+	}
+	if report.Type == "" {
+		in.addIssue(severityError, report.Path, "", "root element must be urlset or sitemapindex")
+		return
+	}
+
+	for _, entry := range indexLocs {
+		filename, err := in.localFilename(entry.Loc)
+		if err != nil {
+			in.addIssue(severityError, report.Path, entry.Loc, "%s", err)
+			continue
+		}
+		if in.visited[filename] {
+			in.addIssue(severityWarn, report.Path, entry.Loc, "sitemap is referenced more than once")
+			continue
+		}
+		in.inspectFile(filename, false)
+	}
+}
+
+// inspectURL validates a <url> entry and adds it to the stats.
+func (in *inspector) inspectURL(report *fileReport, entry *urlEntry) {
+	report.URLCount++
+	in.result.URLCount++
+
+	u, err := validLoc(entry.Loc)
+	if err != nil {
+		in.addIssue(severityError, report.Path, entry.Loc, "%s", err)
+	} else {
+		in.result.URLsByHost[u.Host]++
+	}
+	if first, ok := in.result.seen[entry.Loc]; ok {
+		in.addIssue(severityWarn, report.Path, entry.Loc, "duplicate of the URL in %s", first)
+	} else {
+		in.result.seen[entry.Loc] = report.Path
+	}
+
+	// the protocol values are lowercase which ParseChangeFreq does not enforce
+	if _, err := source.ParseChangeFreq(entry.ChangeFreq); err != nil || entry.ChangeFreq != strings.ToLower(entry.ChangeFreq) {
+		in.addIssue(severityError, report.Path, entry.Loc, "invalid changefreq %q", entry.ChangeFreq)
+	}
+	if _, err := source.ParsePriority(entry.Priority); err != nil {
+		in.addIssue(severityError, report.Path, entry.Loc, "%s", err)
+	}
+	lastMod := in.inspectLastMod(report, entry)
+	if lastMod.IsZero() {
+		in.result.LastModByMonth["none"]++
+	} else {
+		in.result.LastModByMonth[lastMod.UTC().Format("2006-01")]++
+	}
+}
+
+// inspectLastMod validates the lastmod of entry and updates the min and max
+// lastmod of report. returns zero time in case of absence or invalid value.
+func (in *inspector) inspectLastMod(report *fileReport, entry *urlEntry) time.Time {
+	if entry.LastMod == "" {
+		return time.Time{}
+	}
+	lastMod, ok := parseW3CDatetime(strings.TrimSpace(entry.LastMod))
+	if !ok {
+		in.addIssue(severityError, report.Path, entry.Loc, "invalid lastmod %q", entry.LastMod)
+		return time.Time{}
+	}
+	if report.minLastMod.IsZero() || lastMod.Before(report.minLastMod) {
+		report.minLastMod = lastMod
+	}
+	if lastMod.After(report.maxLastMod) {
+		report.maxLastMod = lastMod
+	}
+	return lastMod
+}
+
+// localFilename maps the loc of an index entry to a local file under root.
+// The ServerURI prefix is removed from the URL path and the base filename
+// is used in case of absence of the full path.
+func (in *inspector) localFilename(loc string) (string, error) {
+	u, err := validLoc(loc)
+	if err != nil {
+		return "", err
+	}
+	urlPath := u.Path
+	if in.serverURI != "" {
+		urlPath = strings.TrimPrefix(urlPath, path.Clean("/"+in.serverURI))
+	}
+	filename := filepath.Join(in.root, filepath.FromSlash(path.Clean("/"+urlPath)))
+	if _, err := os.Stat(filename); err == nil {
+		return filename, nil
+	}
+	filename = filepath.Join(in.root, path.Base(u.Path))
+	if _, err := os.Stat(filename); err != nil {
+		return "", fmt.Errorf("broken reference, file does not exist under %s", in.root)
+	}
+	return filename, nil
+}
+
+// validLoc parses and validates the loc which must be an absolute http(s) URL.
+func validLoc(loc string) (*url.URL, error) {
+	if loc == "" {
+		return nil, fmt.Errorf("loc is empty")
+	}
+	if len(loc) > maxLocLength {
+		return nil, fmt.Errorf("loc is longer than %d characters", maxLocLength)
+	}
+	u, err := url.Parse(loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid loc: %s", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("loc must be an absolute http or https URL")
+	}
+	return u, nil
+}
+
+func parseW3CDatetime(value string) (time.Time, bool) {
+	for _, layout := range w3cLayouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,64 @@
+// Command smg generates sitemaps from data files without writing any Go code
+// and validates the generated or existing sitemaps.
+//
+// Usage:
+//
+//	smg <command> [flags] [files...]
+//
+// The commands are:
+//
+//	generate    reads URLs from CSV, TSV, JSON Lines or text files and
+//	            writes a sitemap or a sitemap index with its sitemaps
+//	run         builds a sitemap index with its sitemaps which are described
+//	            by a YAML or JSON config file
+//	validate    checks a sitemap or a sitemap index with its sitemaps
+//	            against the protocol and exits with 1 in case of errors
+//	stats       reports the URL counts, file sizes, lastmod distribution
+//	            and hosts of a sitemap or a sitemap index with its sitemaps
+//
+// Use "smg <command> -h" for more information about a command.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage: smg <command> [flags] [files...]
+
+Commands:
+  generate    generate a sitemap or sitemap index from data files
+  run         generate the sitemaps which are described by a config file
+  validate    validate a sitemap or sitemap index and its sitemaps
+  stats       report the stats of a sitemap or sitemap index and its sitemaps
+
+Use "smg <command> -h" for more information about a command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command of args and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	switch args[0] {
+	case "generate":
+		return runGenerate(args[1:], stdin, stdout, stderr)
+	case "run":
+		return runConfig(args[1:], stdout, stderr)
+	case "validate":
+		return runValidate(args[1:], stdout, stderr)
+	case "stats":
+		return runStats(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+	fmt.Fprintf(stderr, "smg: unknown command %q\n%s", args[0], usage)
+	return 2
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/sabloger/sitemap-generator/config"
+)
+
+// runConfig loads a config file and saves the sitemap index which is described by it.
+// sql sources are not supported since no database driver is linked into the command.
+func runConfig(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, "Usage: smg run config.yaml\n\n"+
+			"Builds and saves the sitemap index and sitemaps which are described by a YAML or JSON config file.\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	c, err := config.Load(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(stderr, "smg run:", err)
+		return 1
+	}
+	filename, err := c.Run(context.Background())
+	if err != nil {
+		fmt.Fprintln(stderr, "smg run:", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, filename)
+	return 0
+}
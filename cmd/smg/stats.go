@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// runStats reports the counts, sizes, lastmod distribution and hosts of a
+// sitemap or sitemap index with its sitemaps.
+// The exit code is 1 in case of any error issue.
+func runStats(args []string, stdout, stderr io.Writer) int {
+	f, filename, code := parseInspectFlags("stats",
+		"Reports the stats of a sitemap or sitemap index and the sitemaps which are referenced by it.", args, stderr)
+	if code >= 0 {
+		return code
+	}
+
+	result := inspect(filename, *f.root, *f.serverURI)
+	if *f.json {
+		err := writeJSON(stdout, result)
+		if err != nil {
+			fmt.Fprintln(stderr, "smg stats:", err)
+			return 1
+		}
+	} else {
+		writeStats(stdout, result)
+	}
+
+	if n := result.errorsCount(); n > 0 {
+		fmt.Fprintf(stderr, "smg stats: %d errors, run validate for details\n", n)
+		return 1
+	}
+	return 0
+}
+
+// writeStats writes the stats of result in a human readable format.
+func writeStats(w io.Writer, result *inspection) {
+	var size, uncompressedSize int64
+	fmt.Fprintln(w, "Files:")
+	for _, f := range result.Files {
+		fmt.Fprintf(w, "  %s\t%s\t%d URLs\t%d bytes\t%d bytes uncompressed\n",
+			f.Path, f.Type, f.URLCount, f.Size, f.UncompressedSize)
+		size += f.Size
+		uncompressedSize += f.UncompressedSize
+	}
+	fmt.Fprintf(w, "Total: %d files, %d URLs, %d bytes, %d bytes uncompressed\n",
+		len(result.Files), result.URLCount, size, uncompressedSize)
+
+	fmt.Fprintln(w, "Lastmod by month:")
+	for _, month := range sortedKeys(result.LastModByMonth) {
+		fmt.Fprintf(w, "  %s\t%d\n", month, result.LastModByMonth[month])
+	}
+	fmt.Fprintln(w, "URLs by host:")
+	for _, host := range sortedKeys(result.URLsByHost) {
+		fmt.Fprintf(w, "  %s\t%d\n", host, result.URLsByHost[host])
+	}
+}
+
+// sortedKeys returns the keys of m in order and "none" as the last one.
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if (keys[i] == "none") != (keys[j] == "none") {
+			return keys[j] == "none"
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
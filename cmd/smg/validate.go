@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// inspectFlags are the common flags of validate and stats commands.
+type inspectFlags struct {
+	root      *string
+	serverURI *string
+	json      *bool
+}
+
+// parseInspectFlags parses the args of validate and stats commands and
+// returns the sitemap filename. code is non-negative in case of returning.
+func parseInspectFlags(command, description string, args []string, stderr io.Writer) (f inspectFlags, filename string, code int) {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: smg %s [flags] file\n\n%s\n\n", command, description)
+		flags.PrintDefaults()
+	}
+	f.root = flags.String("root", "", "local directory of the sitemap files which are referenced by the index (default is the directory of file)")
+	f.serverURI = flags.String("server-uri", "", "path of sitemap files on the server which is removed from the referenced URLs")
+	f.json = flags.Bool("json", false, "write the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return f, "", 0
+		}
+		return f, "", 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return f, "", 2
+	}
+	return f, flags.Arg(0), -1
+}
+
+// runValidate checks a sitemap or sitemap index with its sitemaps against the protocol.
+// The exit code is 1 in case of any error issue.
+func runValidate(args []string, stdout, stderr io.Writer) int {
+	f, filename, code := parseInspectFlags("validate",
+		"Validates a sitemap or sitemap index and the sitemaps which are referenced by it.", args, stderr)
+	if code >= 0 {
+		return code
+	}
+
+	result := inspect(filename, *f.root, *f.serverURI)
+	errorsCount := result.errorsCount()
+	if *f.json {
+		err := writeJSON(stdout, struct {
+			Valid    bool     `json:"valid"`
+			Errors   int      `json:"errors"`
+			Warnings int      `json:"warnings"`
+			Files    int      `json:"files"`
+			URLCount int      `json:"url_count"`
+			Issues   []*issue `json:"issues"`
+		}{
+			Valid:    errorsCount == 0,
+			Errors:   errorsCount,
+			Warnings: len(result.Issues) - errorsCount,
+			Files:    len(result.Files),
+			URLCount: result.URLCount,
+			Issues:   result.Issues,
+		})
+		if err != nil {
+			fmt.Fprintln(stderr, "smg validate:", err)
+			return 1
+		}
+	} else {
+		for _, i := range result.Issues {
+			if i.Loc != "" {
+				fmt.Fprintf(stdout, "%s: %s: %s: %s\n", i.File, i.Severity, i.Loc, i.Message)
+			} else {
+				fmt.Fprintf(stdout, "%s: %s: %s\n", i.File, i.Severity, i.Message)
+			}
+		}
+		fmt.Fprintf(stdout, "%d files, %d URLs, %d errors, %d warnings\n",
+			len(result.Files), result.URLCount, errorsCount, len(result.Issues)-errorsCount)
+	}
+
+	if errorsCount > 0 {
+		return 1
+	}
+	return 0
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
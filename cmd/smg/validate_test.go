@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidateGeneratedSitemapIndex tests validating and reporting the stats of a generated sitemap index
+func TestValidateGeneratedSitemapIndex(t *testing.T) {
+	path := t.TempDir()
+	input := filepath.Join(path, "export.csv")
+	err := os.WriteFile(input, []byte("loc,lastmod\n/a,2022-02-12\n/b,2022-03-13\n/c,\n"), 0666)
+	if err != nil {
+		t.Fatal("Unable to write input:", err)
+	}
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run([]string{"generate", "-index", "-hostname", "https://www.example.com", "-server-uri", "/sitemaps/",
+		"-output", path, "-name", "index", "-max-urls", "2", input}, nil, stdout, stderr)
+	assert.Equal(t, 0, code, stderr.String())
+
+	stdout.Reset()
+	code = run([]string{"validate", "-server-uri", "/sitemaps/", filepath.Join(path, "index.xml.gz")}, nil, stdout, stderr)
+	assert.Equal(t, 0, code, stdout.String())
+	assert.Equal(t, "3 files, 3 URLs, 0 errors, 0 warnings\n", stdout.String())
+
+	stdout.Reset()
+	code = run([]string{"stats", "-json", filepath.Join(path, "index.xml.gz")}, nil, stdout, stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	var result inspection
+	err = json.Unmarshal(stdout.Bytes(), &result)
+	if err != nil {
+		t.Fatal("Unable to decode stats:", err)
+	}
+	assert.Len(t, result.Files, 3)
+	assert.Equal(t, "sitemapindex", result.Files[0].Type)
+	assert.Equal(t, 2, result.Files[0].URLCount)
+	assert.True(t, result.Files[1].Compressed)
+	assert.Greater(t, result.Files[1].UncompressedSize, result.Files[1].Size)
+	assert.Equal(t, 3, result.URLCount)
+	assert.Equal(t, map[string]int{"2022-02": 1, "2022-03": 1, "none": 1}, result.LastModByMonth)
+	assert.Equal(t, map[string]int{"www.example.com": 3}, result.URLsByHost)
+}
+
+// TestValidateInvalidSitemapIndex tests reporting the protocol violations, duplicates and broken references
+func TestValidateInvalidSitemapIndex(t *testing.T) {
+	path := t.TempDir()
+	files := map[string]string{
+		"index.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<sitemap><loc>https://www.example.com/part1.xml</loc><lastmod>2022-02-12</lastmod></sitemap>
+<sitemap><loc>https://www.example.com/missing.xml</loc></sitemap>
+<sitemap><loc>https://www.example.com/part2.txt</loc></sitemap>
+</sitemapindex>`,
+		"part1.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>https://www.example.com/a</loc><lastmod>12/02/2022</lastmod><changefreq>Daily</changefreq></url>
+<url><loc>/relative</loc><priority>1.5</priority></url>
+</urlset>`,
+		"part2.txt": "https://www.example.com/a\nhttps://other.example.com/b\n",
+	}
+	for filename, content := range files {
+		err := os.WriteFile(filepath.Join(path, filename), []byte(content), 0666)
+		if err != nil {
+			t.Fatal("Unable to write file:", err)
+		}
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run([]string{"validate", "-json", filepath.Join(path, "index.xml")}, nil, stdout, stderr)
+	assert.Equal(t, 1, code)
+	var report struct {
+		Valid    bool     `json:"valid"`
+		Errors   int      `json:"errors"`
+		Warnings int      `json:"warnings"`
+		Issues   []*issue `json:"issues"`
+	}
+	err := json.Unmarshal(stdout.Bytes(), &report)
+	if err != nil {
+		t.Fatal("Unable to decode report:", err)
+	}
+	assert.False(t, report.Valid)
+	assert.Equal(t, 5, report.Errors)
+	assert.Equal(t, 1, report.Warnings)
+
+	messages := make([]string, len(report.Issues))
+	for i, issue := range report.Issues {
+		messages[i] = issue.Loc + " " + issue.Message
+	}
+	assert.Contains(t, messages, `https://www.example.com/a invalid lastmod "12/02/2022"`)
+	assert.Contains(t, messages, `https://www.example.com/a invalid changefreq "Daily"`)
+	assert.Contains(t, messages, "/relative loc must be an absolute http or https URL")
+	assert.Contains(t, messages, `/relative invalid priority "1.5"`)
+	assert.Contains(t, messages, "https://www.example.com/missing.xml broken reference, file does not exist under "+path)
+	assert.Contains(t, messages, "https://www.example.com/a duplicate of the URL in "+filepath.Join(path, "part1.xml"))
+
+	stdout.Reset()
+	code = run([]string{"stats", filepath.Join(path, "index.xml")}, nil, stdout, stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stdout.String(), "  other.example.com\t1\n  www.example.com\t2\n")
+
+	code = run([]string{"validate"}, nil, stdout, stderr)
+	assert.Equal(t, 2, code)
+}
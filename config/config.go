@@ -0,0 +1,437 @@
+// Package config loads the declarative YAML or JSON description of the whole sitemap set
+// of a website and builds and saves its SitemapIndex using the adapters of source package.
+package config
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sabloger/sitemap-generator/smg"
+	"github.com/sabloger/sitemap-generator/source"
+	"gopkg.in/yaml.v3"
+)
+
+// Source types of SourceConfig
+const (
+	FileSource    = "file"
+	DirSource     = "dir"
+	CommandSource = "command"
+	SQLSource     = "sql"
+)
+
+// Config describes the SitemapIndex of a website and its named Sitemaps.
+// Compress is enabled by default. HashFilenames appends the content hash to the Sitemap filenames.
+// Manifest is the name of the JSON manifest of written files without extension, empty disables it.
+// GzipLevel is the level of compressed files, zero keeps the default. BothVariants writes both the
+// uncompressed and the compressed files while Compress designates the referenced variant.
+type Config struct {
+	Name          string           `yaml:"name" json:"name"`
+	Hostname      string           `yaml:"hostname" json:"hostname"`
+	OutputPath    string           `yaml:"output_path" json:"output_path"`
+	ServerURI     string           `yaml:"server_uri" json:"server_uri"`
+	Compress      *bool            `yaml:"compress" json:"compress"`
+	PrettyPrint   bool             `yaml:"pretty_print" json:"pretty_print"`
+	HashFilenames bool             `yaml:"hash_filenames" json:"hash_filenames"`
+	Manifest      string           `yaml:"manifest" json:"manifest"`
+	GzipLevel     int              `yaml:"gzip_level" json:"gzip_level"`
+	BothVariants  bool             `yaml:"both_variants" json:"both_variants"`
+	Sitemaps      []*SitemapConfig `yaml:"sitemaps" json:"sitemaps"`
+}
+
+// SitemapConfig describes a named Sitemap of the SitemapIndex and the source of its URLs.
+// ChangeFreq and Priority are the defaults of the URLs which have no such values.
+// MaxURLs and MaxBytes limit the URLs count and uncompressed size of each part, zero keeps the protocol maxima.
+// MaxGzipBytes limits the compressed size of each part, zero disables it.
+type SitemapConfig struct {
+	Name         string         `yaml:"name" json:"name"`
+	ChangeFreq   smg.ChangeFreq `yaml:"changefreq" json:"changefreq"`
+	Priority     float32        `yaml:"priority" json:"priority"`
+	MaxURLs      int            `yaml:"max_urls" json:"max_urls"`
+	MaxBytes     int            `yaml:"max_bytes" json:"max_bytes"`
+	MaxGzipBytes int            `yaml:"max_gzip_bytes" json:"max_gzip_bytes"`
+	Source       SourceConfig   `yaml:"source" json:"source"`
+}
+
+// SourceConfig describes the source of URLs of a Sitemap which Type is one of file, dir, command or sql.
+//
+// file reads the data file of Path in Format which is detected by extension in case of absence.
+// dir walks the static site directory of Path using the dir rules which default to source.DefaultDirRules.
+// command runs Command and reads its standard output in Format which is text by default.
+// sql runs Query of the database which is opened by Driver and DSN; the driver must be
+// registered by importing it in the program.
+type SourceConfig struct {
+	Type            string         `yaml:"type" json:"type"`
+	Path            string         `yaml:"path" json:"path"`
+	Format          string         `yaml:"format" json:"format"`
+	Columns         *ColumnsConfig `yaml:"columns" json:"columns"`
+	ImagesSeparator string         `yaml:"images_separator" json:"images_separator"`
+
+	IndexFiles      []string `yaml:"index_files" json:"index_files"`
+	Extensions      []string `yaml:"extensions" json:"extensions"`
+	StripExtensions *bool    `yaml:"strip_extensions" json:"strip_extensions"`
+	Exclude         []string `yaml:"exclude" json:"exclude"`
+	LastMod         string   `yaml:"lastmod" json:"lastmod"`
+	HashFile        string   `yaml:"hash_file" json:"hash_file"`
+
+	Command []string `yaml:"command" json:"command"`
+
+	Driver      string        `yaml:"driver" json:"driver"`
+	DSN         string        `yaml:"dsn" json:"dsn"`
+	Query       string        `yaml:"query" json:"query"`
+	Args        []interface{} `yaml:"args" json:"args"`
+	KeyColumn   string        `yaml:"key_column" json:"key_column"`
+	PageSize    int           `yaml:"page_size" json:"page_size"`
+	Placeholder string        `yaml:"placeholder" json:"placeholder"`
+}
+
+// ColumnsConfig maps the columns of file, command and sql sources. Empty values use source.DefaultColumns.
+type ColumnsConfig struct {
+	Loc        string `yaml:"loc" json:"loc"`
+	LastMod    string `yaml:"lastmod" json:"lastmod"`
+	ChangeFreq string `yaml:"changefreq" json:"changefreq"`
+	Priority   string `yaml:"priority" json:"priority"`
+	Images     string `yaml:"images" json:"images"`
+}
+
+// Load reads and validates the config file which is parsed as JSON in case
+// of .json extension and as YAML otherwise. Unknown fields are rejected.
+// The relative output, source and hash file paths are resolved against
+// the directory of the config file.
+func Load(filename string) (*Config, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c, err := Parse(f, strings.EqualFold(filepath.Ext(filename), ".json"))
+	if err != nil {
+		return nil, err
+	}
+	c.resolvePaths(filepath.Dir(filename))
+	return c, nil
+}
+
+// resolvePaths resolves the relative paths of the config against dir.
+func (c *Config) resolvePaths(dir string) {
+	c.OutputPath = resolvePath(dir, c.OutputPath)
+	for _, sm := range c.Sitemaps {
+		sm.Source.Path = resolvePath(dir, sm.Source.Path)
+		sm.Source.HashFile = resolvePath(dir, sm.Source.HashFile)
+	}
+}
+
+// resolvePath joins the relative path p to dir. Empty and absolute paths are kept.
+func resolvePath(dir, p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
+
+// Parse reads and validates the config of r as JSON or YAML.
+func Parse(r io.Reader, isJSON bool) (*Config, error) {
+	c := &Config{}
+	var err error
+	if isJSON {
+		decoder := json.NewDecoder(r)
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(c)
+	} else {
+		decoder := yaml.NewDecoder(r)
+		decoder.KnownFields(true)
+		err = decoder.Decode(c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	err = c.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate checks the required fields and values of the config.
+func (c *Config) Validate() error {
+	if c.Hostname == "" {
+		return errors.New("hostname is required")
+	}
+	if len(c.Sitemaps) == 0 {
+		return errors.New("at least one sitemap is required")
+	}
+	names := make(map[string]bool)
+	for i, sm := range c.Sitemaps {
+		if sm.Name == "" {
+			return fmt.Errorf("sitemaps[%d]: name is required", i)
+		}
+		if names[sm.Name] {
+			return fmt.Errorf("sitemap %s: duplicate name", sm.Name)
+		}
+		names[sm.Name] = true
+		err := sm.validate()
+		if err != nil {
+			return fmt.Errorf("sitemap %s: %w", sm.Name, err)
+		}
+	}
+	return nil
+}
+
+func (s *SitemapConfig) validate() error {
+	if _, err := source.ParseChangeFreq(string(s.ChangeFreq)); err != nil {
+		return err
+	}
+	if s.Priority < 0 || s.Priority > 1 {
+		return fmt.Errorf("invalid priority %v", s.Priority)
+	}
+	if s.MaxURLs < 0 || s.MaxURLs > smg.MaxURLsCount {
+		return fmt.Errorf("invalid max_urls %d", s.MaxURLs)
+	}
+	if s.MaxBytes < 0 || s.MaxBytes > smg.MaxFileSize {
+		return fmt.Errorf("invalid max_bytes %d", s.MaxBytes)
+	}
+	if s.MaxGzipBytes < 0 {
+		return fmt.Errorf("invalid max_gzip_bytes %d", s.MaxGzipBytes)
+	}
+
+	src := &s.Source
+	switch src.Type {
+	case FileSource, DirSource:
+		if src.Path == "" {
+			return fmt.Errorf("path is required for %s source", src.Type)
+		}
+	case CommandSource:
+		if len(src.Command) == 0 {
+			return errors.New("command is required for command source")
+		}
+	case SQLSource:
+		if src.Driver == "" || src.Query == "" || src.KeyColumn == "" {
+			return errors.New("driver, query and key_column are required for sql source")
+		}
+	default:
+		return fmt.Errorf("unknown source type %q", src.Type)
+	}
+	if src.Format != "" {
+		if _, err := source.ParseFileFormat(src.Format); err != nil {
+			return err
+		}
+	}
+	if _, err := src.lastModSource(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Build builds the SitemapIndex and reads the URLs of all sources into its Sitemaps.
+func (c *Config) Build(ctx context.Context) (*smg.SitemapIndex, error) {
+	smi := smg.NewSitemapIndex(c.PrettyPrint)
+	if c.Name != "" {
+		smi.SetSitemapIndexName(c.Name)
+	}
+	smi.SetHostname(c.Hostname)
+	smi.SetOutputPath(c.OutputPath)
+	smi.SetServerURI(c.ServerURI)
+	if c.Compress != nil {
+		smi.SetCompress(*c.Compress)
+	}
+	smi.SetHashFilenames(c.HashFilenames)
+	smi.SetManifestName(c.Manifest)
+	smi.SetWriteBothVariants(c.BothVariants)
+	if c.GzipLevel != 0 {
+		err := smi.SetCompressionLevel(c.GzipLevel)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range c.Sitemaps {
+		sm := smi.NewSitemap()
+		sm.SetName(s.Name)
+		if s.MaxURLs > 0 {
+			sm.SetMaxURLsCount(s.MaxURLs)
+		}
+		if s.MaxBytes > 0 {
+			err := sm.SetMaxFileSize(s.MaxBytes)
+			if err != nil {
+				return nil, fmt.Errorf("sitemap %s: %w", s.Name, err)
+			}
+		}
+		err := sm.SetMaxCompressedSize(s.MaxGzipBytes)
+		if err != nil {
+			return nil, fmt.Errorf("sitemap %s: %w", s.Name, err)
+		}
+		err = s.Source.read(ctx, s, sm)
+		if err != nil {
+			return nil, fmt.Errorf("sitemap %s: %w", s.Name, err)
+		}
+	}
+	return smi, nil
+}
+
+// Run builds and saves the SitemapIndex. returns the filename of SitemapIndex.
+func (c *Config) Run(ctx context.Context) (string, error) {
+	smi, err := c.Build(ctx)
+	if err != nil {
+		return "", err
+	}
+	return smi.Save()
+}
+
+// read reads the URLs of the source into the sink using the defaults of the Sitemap.
+func (s *SourceConfig) read(ctx context.Context, defaults *SitemapConfig, sink source.Sink) error {
+	columns := s.columns()
+	columns.DefaultChangeFreq = defaults.ChangeFreq
+	columns.DefaultPriority = defaults.Priority
+	switch s.Type {
+	case FileSource:
+		f, err := os.Open(s.Path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		format, err := s.fileFormat(s.Path)
+		if err != nil {
+			return err
+		}
+		return source.ReadFile(f, format, columns, sink)
+	case DirSource:
+		rules, err := s.dirRules()
+		if err != nil {
+			return err
+		}
+		rules.ChangeFreq = defaults.ChangeFreq
+		rules.Priority = defaults.Priority
+		return source.WalkDir(s.Path, rules, sink)
+	case CommandSource:
+		return s.readCommand(ctx, columns, sink)
+	case SQLSource:
+		db, err := sql.Open(s.Driver, s.DSN)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		query := source.SQLQuery{
+			Query:       s.Query,
+			Args:        s.Args,
+			KeyColumn:   s.KeyColumn,
+			PageSize:    s.PageSize,
+			Placeholder: s.Placeholder,
+		}
+		return source.ReadSQL(ctx, db, query, columns, sink)
+	}
+	return fmt.Errorf("unknown source type %q", s.Type)
+}
+
+// readCommand runs the command and reads its standard output. The standard error
+// output is included in the error in case of failure.
+func (s *SourceConfig) readCommand(ctx context.Context, columns source.Columns, sink source.Sink) error {
+	format := source.Text
+	if s.Format != "" {
+		var err error
+		format, err = source.ParseFileFormat(s.Format)
+		if err != nil {
+			return err
+		}
+	}
+	cmd := exec.CommandContext(ctx, s.Command[0], s.Command[1:]...)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+	readErr := source.ReadFile(stdout, format, columns, sink)
+	if readErr != nil {
+		// drains the output for letting the command exit
+		io.Copy(io.Discard, stdout)
+	}
+	err = cmd.Wait()
+	if err != nil {
+		return fmt.Errorf("command %s: %w: %s", s.Command[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return readErr
+}
+
+func (s *SourceConfig) fileFormat(filename string) (source.FileFormat, error) {
+	if s.Format != "" {
+		return source.ParseFileFormat(s.Format)
+	}
+	return source.FileFormatOf(filename)
+}
+
+// columns returns the columns mapping of the source based on source.DefaultColumns.
+func (s *SourceConfig) columns() source.Columns {
+	columns := source.DefaultColumns
+	if s.ImagesSeparator != "" {
+		columns.ImagesSeparator = s.ImagesSeparator
+	}
+	if s.Columns == nil {
+		return columns
+	}
+	for _, c := range []struct {
+		value  string
+		column *string
+	}{
+		{s.Columns.Loc, &columns.Loc},
+		{s.Columns.LastMod, &columns.LastMod},
+		{s.Columns.ChangeFreq, &columns.ChangeFreq},
+		{s.Columns.Priority, &columns.Priority},
+		{s.Columns.Images, &columns.Images},
+	} {
+		if c.value != "" {
+			*c.column = c.value
+		}
+	}
+	return columns
+}
+
+// dirRules returns the dir rules of the source based on source.DefaultDirRules.
+func (s *SourceConfig) dirRules() (source.DirRules, error) {
+	rules := source.DefaultDirRules
+	if s.IndexFiles != nil {
+		rules.IndexFiles = s.IndexFiles
+	}
+	if s.Extensions != nil {
+		rules.Extensions = s.Extensions
+	}
+	if s.StripExtensions != nil {
+		rules.StripExtensions = *s.StripExtensions
+	}
+	rules.Exclude = s.Exclude
+	rules.HashFile = s.HashFile
+	lastMod, err := s.lastModSource()
+	if err != nil {
+		return rules, err
+	}
+	rules.LastMod = lastMod
+	return rules, nil
+}
+
+// lastModSource parses the lastmod of dir source which is one of modtime, hash or none.
+func (s *SourceConfig) lastModSource() (source.LastModSource, error) {
+	switch strings.ToLower(s.LastMod) {
+	case "", "modtime":
+		return source.ModTime, nil
+	case "hash":
+		if s.HashFile == "" {
+			return 0, errors.New("hash_file is required for hash lastmod")
+		}
+		return source.ContentHash, nil
+	case "none":
+		return source.NoLastMod, nil
+	}
+	return 0, fmt.Errorf("unknown lastmod %q", s.LastMod)
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sabloger/sitemap-generator/smg"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRunYAMLConfig tests building a sitemap index from file, dir and command sources
+// whose relative paths are resolved against the directory of the config file
+func TestRunYAMLConfig(t *testing.T) {
+	path := t.TempDir()
+	site := filepath.Join(path, "public")
+	output := filepath.Join(path, "sitemaps")
+	err := os.MkdirAll(filepath.Join(site, "blog"), 0755)
+	if err != nil {
+		t.Fatal("Unable to make dir:", err)
+	}
+	files := map[string]string{
+		filepath.Join(site, "index.html"):      "<h1>Home</h1>",
+		filepath.Join(site, "blog/index.html"): "<h1>Blog</h1>",
+		filepath.Join(path, "products.csv"):    "url,updated_at,prio\n/p/1,2022-02-12,0.9\n/p/2,,\n/p/3,,0\n",
+		filepath.Join(path, "sitemap.yaml"): `
+name: index
+hostname: https://www.example.com
+output_path: sitemaps
+server_uri: /sitemaps/
+compress: false
+sitemaps:
+  - name: pages
+    changefreq: weekly
+    source:
+      type: dir
+      path: public
+      lastmod: none
+  - name: products
+    priority: 0.5
+    source:
+      type: file
+      path: products.csv
+      columns:
+        loc: url
+        lastmod: updated_at
+        priority: prio
+  - name: tags
+    source:
+      type: command
+      command: [echo, "/tags/go"]
+`,
+	}
+	for filename, content := range files {
+		err = os.WriteFile(filename, []byte(content), 0666)
+		if err != nil {
+			t.Fatal("Unable to write file:", err)
+		}
+	}
+
+	c, err := Load(filepath.Join(path, "sitemap.yaml"))
+	if err != nil {
+		t.Fatal("Unable to load config:", err)
+	}
+	filename, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatal("Unable to run config:", err)
+	}
+	assert.Equal(t, "index.xml", filename)
+
+	index := readFile(t, filepath.Join(output, "index.xml"))
+	for _, name := range []string{"pages.xml", "products.xml", "tags.xml"} {
+		assert.Contains(t, index, "<loc>https://www.example.com/sitemaps/"+name+"</loc>")
+	}
+	pages := readFile(t, filepath.Join(output, "pages.xml"))
+	assert.Contains(t, pages, "<loc>https://www.example.com/blog/</loc>")
+	assert.Contains(t, pages, "<changefreq>weekly</changefreq>")
+	assert.NotContains(t, pages, "<lastmod>")
+	products := readFile(t, filepath.Join(output, "products.xml"))
+	assert.Contains(t, products, "<priority>0.9</priority>")
+	// the explicit priority of 0 is kept
+	assert.Equal(t, 1, strings.Count(products, "<priority>0.5</priority>"))
+	assert.Contains(t, readFile(t, filepath.Join(output, "tags.xml")), "<loc>https://www.example.com/tags/go</loc>")
+}
+
+// TestParseJSONConfig tests parsing and validating JSON configs
+func TestParseJSONConfig(t *testing.T) {
+	c, err := Parse(strings.NewReader(`{"hostname": "https://www.example.com", "sitemaps": [
+		{"name": "db", "changefreq": "daily", "source": {"type": "sql", "driver": "postgres",
+		 "query": "SELECT id, path FROM pages", "key_column": "id", "placeholder": "$%d"}}]}`), true)
+	if err != nil {
+		t.Fatal("Unable to parse config:", err)
+	}
+	assert.Equal(t, smg.Daily, c.Sitemaps[0].ChangeFreq)
+	assert.Equal(t, "$%d", c.Sitemaps[0].Source.Placeholder)
+
+	for _, invalid := range []string{
+		`{"sitemaps": [{"name": "a", "source": {"type": "file", "path": "a.csv"}}]}`,
+		`{"hostname": "https://www.example.com", "sitemaps": []}`,
+		`{"hostname": "https://www.example.com", "sitemaps": [{"name": "a", "source": {"type": "ftp"}}]}`,
+		`{"hostname": "https://www.example.com", "sitemaps": [{"name": "a", "source": {"type": "command"}}]}`,
+		`{"hostname": "https://www.example.com", "sitemaps": [{"name": "a", "priority": 2, "source": {"type": "file", "path": "a.csv"}}]}`,
+		`{"hostname": "https://www.example.com", "sitemaps": [{"name": "a", "source": {"type": "dir", "path": ".", "lastmod": "hash"}}]}`,
+		`{"hostname": "https://www.example.com", "unknown": true, "sitemaps": [{"name": "a", "source": {"type": "file", "path": "a.csv"}}]}`,
+	} {
+		_, err = Parse(strings.NewReader(invalid), true)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func readFile(t *testing.T, filename string) string {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal("Unable to read file:", err)
+	}
+	return string(content)
+}
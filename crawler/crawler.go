@@ -0,0 +1,381 @@
+// Package crawler crawls the HTML pages of a website from a seed URL and feeds
+// the discovered pages as SitemapLoc items into a Sitemap of smg package.
+// It is useful for the websites which have no database of their URLs.
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sabloger/sitemap-generator/internal/htmlmeta"
+	"github.com/sabloger/sitemap-generator/smg"
+	"github.com/sabloger/sitemap-generator/source"
+)
+
+const (
+	defaultMaxDepth    int    = 10
+	defaultConcurrency int    = 4
+	defaultUserAgent   string = "smg-crawler/1.0 (+https://github.com/sabloger/sitemap-generator)"
+	maxRobotsTxtSize   int64  = 500 << 10
+)
+
+// ErrorHandler is called for the pages which could not be crawled.
+type ErrorHandler func(pageURL string, err error)
+
+// Crawler crawls the same-host pages of a website in breadth-first order by following
+// the <a href> and canonical links of HTML pages. It respects the robots.txt rules for its
+// user agent, the Crawl-delay and the noindex and nofollow directives of robots meta tags
+// and X-Robots-Tag headers. Pages which are noindex or have a different canonical URL are
+// followed but not added. The lastmod of pages is taken from their Last-Modified header
+// and the images from their <img> tags.
+type Crawler struct {
+	client       *http.Client
+	userAgent    string
+	maxDepth     int
+	maxPages     int
+	concurrency  int
+	delay        time.Duration
+	errorHandler ErrorHandler
+}
+
+// page is the result of fetching a page. loc is nil for the pages which must not be added.
+type page struct {
+	url   string
+	loc   *smg.SitemapLoc
+	links []string
+}
+
+// New builds and returns a new Crawler with the default settings.
+func New() *Crawler {
+	return &Crawler{
+		client:      http.DefaultClient,
+		userAgent:   defaultUserAgent,
+		maxDepth:    defaultMaxDepth,
+		concurrency: defaultConcurrency,
+	}
+}
+
+// SetHTTPClient sets the http.Client which is used for the requests. Default is http.DefaultClient.
+func (c *Crawler) SetHTTPClient(client *http.Client) {
+	c.client = client
+}
+
+// SetUserAgent sets the User-Agent header of requests which its product token
+// is also used for matching the robots.txt groups.
+func (c *Crawler) SetUserAgent(userAgent string) {
+	c.userAgent = userAgent
+}
+
+// SetMaxDepth sets the maximum # of links between the seed and crawled pages.
+// Zero crawls only the seed page. Default is 10.
+func (c *Crawler) SetMaxDepth(maxDepth int) {
+	c.maxDepth = maxDepth
+}
+
+// SetMaxPages sets the maximum # of fetched pages. Default is zero which means no limit.
+func (c *Crawler) SetMaxPages(maxPages int) {
+	c.maxPages = maxPages
+}
+
+// SetConcurrency sets the maximum # of concurrent requests. Default is 4.
+func (c *Crawler) SetConcurrency(concurrency int) {
+	c.concurrency = concurrency
+}
+
+// SetDelay sets the minimum delay between starting the requests for limiting the rate.
+// The Crawl-delay of robots.txt is used in case of being longer. Default is zero.
+func (c *Crawler) SetDelay(delay time.Duration) {
+	c.delay = delay
+}
+
+// SetErrorHandler sets the handler which is called for the pages which could not be crawled.
+// Such pages are skipped without stopping the crawl. The handler is called concurrently.
+func (c *Crawler) SetErrorHandler(handler ErrorHandler) {
+	c.errorHandler = handler
+}
+
+// Crawl crawls the website from the seed URL and adds the discovered pages into the sink
+// which is usually a Sitemap or a Sitemap of SitemapIndex made by its NewSitemap method.
+// Pages are added in breadth-first order from the calling goroutine.
+// It returns an error in case of invalid seed, unavailable robots.txt, failed sink or done ctx.
+func (c *Crawler) Crawl(ctx context.Context, seed string, sink source.Sink) error {
+	seedURL, err := url.Parse(seed)
+	if err != nil {
+		return err
+	}
+	if (seedURL.Scheme != "http" && seedURL.Scheme != "https") || seedURL.Host == "" {
+		return errors.New("seed must be an absolute http or https URL")
+	}
+	normalize(seedURL)
+
+	robots, err := c.fetchRobotsTxt(ctx, seedURL)
+	if err != nil {
+		return err
+	}
+	lim := &limiter{delay: c.delay}
+	if robots.crawlDelay > lim.delay {
+		lim.delay = robots.crawlDelay
+	}
+
+	visited := map[string]bool{seedURL.String(): true}
+	added := make(map[string]bool)
+	level := []string{seedURL.String()}
+	fetched := 0
+	for depth := 0; len(level) > 0 && depth <= c.maxDepth; depth++ {
+		batch := make([]string, 0, len(level))
+		for _, u := range level {
+			if c.maxPages > 0 && fetched >= c.maxPages {
+				break
+			}
+			if !robots.allowed(requestPath(u)) {
+				continue
+			}
+			batch = append(batch, u)
+			fetched++
+		}
+
+		pages := c.fetchAll(ctx, seedURL, lim, batch)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		next := make([]string, 0)
+		for _, p := range pages {
+			if p == nil {
+				continue
+			}
+			visited[p.url] = true
+			if p.loc != nil && !added[p.loc.Loc] {
+				added[p.loc.Loc] = true
+				err = sink.Add(p.loc)
+				if err != nil {
+					return err
+				}
+			}
+			for _, link := range p.links {
+				if !visited[link] {
+					visited[link] = true
+					next = append(next, link)
+				}
+			}
+		}
+		level = next
+	}
+	return nil
+}
+
+// fetchAll fetches the pages of batch concurrently and returns them in order of batch.
+func (c *Crawler) fetchAll(ctx context.Context, seed *url.URL, lim *limiter, batch []string) []*page {
+	concurrency := c.concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	pages := make([]*page, len(batch))
+	semaphore := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for i, u := range batch {
+		if lim.wait(ctx) != nil {
+			break
+		}
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			p, err := c.fetch(ctx, seed, u)
+			if err != nil {
+				if c.errorHandler != nil && ctx.Err() == nil {
+					c.errorHandler(u, err)
+				}
+				return
+			}
+			pages[i] = p
+		}(i, u)
+	}
+	wg.Wait()
+	return pages
+}
+
+// fetch requests the page and extracts its SitemapLoc and links.
+// returns nil page without error for the pages which are not HTML or are redirected to other hosts.
+func (c *Crawler) fetch(ctx context.Context, seed *url.URL, pageURL string) (*page, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	finalURL := *resp.Request.URL
+	normalize(&finalURL)
+	if !sameSite(seed, &finalURL) {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, nil
+	}
+
+	doc, err := htmlmeta.Parse(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	noIndex, noFollow := htmlmeta.ParseRobots(resp.Header.Get("X-Robots-Tag"))
+	noIndex = noIndex || doc.NoIndex
+	noFollow = noFollow || doc.NoFollow
+
+	base := &finalURL
+	if doc.Base != "" {
+		if baseURL, err := base.Parse(doc.Base); err == nil {
+			base = baseURL
+		}
+	}
+	p := &page{url: finalURL.String()}
+
+	canonical := p.url
+	if doc.Canonical != "" {
+		if canonicalURL, err := base.Parse(doc.Canonical); err == nil {
+			normalize(canonicalURL)
+			canonical = canonicalURL.String()
+			if sameSite(seed, canonicalURL) && canonical != p.url {
+				p.links = append(p.links, canonical)
+			}
+		}
+	}
+
+	if !noIndex && canonical == p.url {
+		p.loc = &smg.SitemapLoc{Loc: p.url}
+		if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+			p.loc.LastMod = &lastModified
+		}
+		seen := make(map[string]bool)
+		for _, src := range doc.Images {
+			imageURL, err := base.Parse(strings.TrimSpace(src))
+			if err != nil || (imageURL.Scheme != "http" && imageURL.Scheme != "https") {
+				continue
+			}
+			imageURL.Fragment = ""
+			if !seen[imageURL.String()] {
+				seen[imageURL.String()] = true
+				p.loc.Images = append(p.loc.Images, &smg.SitemapImage{ImageLoc: imageURL.String()})
+			}
+		}
+	}
+
+	if !noFollow {
+		for _, href := range doc.Links {
+			linkURL, err := base.Parse(strings.TrimSpace(href))
+			if err != nil {
+				continue
+			}
+			normalize(linkURL)
+			if sameSite(seed, linkURL) {
+				p.links = append(p.links, linkURL.String())
+			}
+		}
+	}
+	return p, nil
+}
+
+// fetchRobotsTxt fetches and parses the robots.txt of the seed host.
+// All pages are allowed in case of 4xx status codes and an error
+// is returned in case of unreachable robots.txt.
+func (c *Crawler) fetchRobotsTxt(ctx context.Context, seed *url.URL) (*robotsRules, error) {
+	robotsURL := url.URL{Scheme: seed.Scheme, Host: seed.Host, Path: "/robots.txt"}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("robots.txt is unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return parseRobotsTxt(io.LimitReader(resp.Body, maxRobotsTxtSize), c.userAgent)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return &robotsRules{}, nil
+	}
+	return nil, fmt.Errorf("robots.txt is unavailable: unexpected status %s", resp.Status)
+}
+
+// limiter limits the rate of requests by delaying them.
+type limiter struct {
+	delay time.Duration
+	next  time.Time
+	mutex sync.Mutex
+}
+
+// wait blocks until the next request is allowed or ctx is done.
+func (l *limiter) wait(ctx context.Context) error {
+	if l.delay <= 0 {
+		return ctx.Err()
+	}
+	l.mutex.Lock()
+	at := time.Now()
+	if l.next.After(at) {
+		at = l.next
+	}
+	l.next = at.Add(l.delay)
+	l.mutex.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// normalize removes the fragment and lowercases the scheme and host of u.
+func normalize(u *url.URL) {
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+		u.RawPath = ""
+	}
+}
+
+// sameSite checks whether u has the same scheme and host of seed.
+func sameSite(seed, u *url.URL) bool {
+	return u.Scheme == seed.Scheme && u.Host == seed.Host
+}
+
+// requestPath returns the path with query of rawURL which is matched against robots.txt rules.
+func requestPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "/"
+	}
+	return u.RequestURI()
+}
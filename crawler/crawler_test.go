@@ -0,0 +1,200 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sabloger/sitemap-generator/smg"
+	"github.com/stretchr/testify/assert"
+)
+
+// sliceSink collects the added SitemapLoc items.
+type sliceSink []*smg.SitemapLoc
+
+func (s *sliceSink) Add(u *smg.SitemapLoc) error {
+	*s = append(*s, u)
+	return nil
+}
+
+func (s sliceSink) locs() []string {
+	locs := make([]string, len(s))
+	for i, u := range s {
+		locs[i] = u.Loc
+	}
+	return locs
+}
+
+var testSite = map[string]string{
+	"/robots.txt": "User-agent: other\nDisallow: /\n\nUser-agent: *\nDisallow: /private\nAllow: /private/public$\n",
+	"/": `<html><head><title>Home</title></head><body>
+<a href="/a">A</a> <a href="/b#section">B</a> <a href="/private/x">Private</a> <a href="/private/public">Public</a>
+<a href="https://other.example.com/x">Other</a> <a href="mailto:info@example.com">Mail</a>
+<a href="/noindex">No index</a> <a href="/nofollow">No follow</a> <a href="/redirect">Redirect</a>
+<a href="/file.pdf">File</a> <a href="/missing">Missing</a>
+<img src="/logo.png"><img src="/logo.png"></body></html>`,
+	"/a":              `<link rel="canonical" href="/a"><a href="/deep">Deep</a><img src="https://cdn.example.com/a.jpg">`,
+	"/b":              `<link rel="canonical" href="/a">`,
+	"/private/public": `<p>public</p>`,
+	"/noindex":        `<meta name="robots" content="noindex"><a href="/from-noindex">From no index</a>`,
+	"/nofollow":       `<meta name="robots" content="nofollow"><a href="/hidden">Hidden</a>`,
+	"/from-noindex":   `<p>from no index</p>`,
+	"/deep":           `<a href="/deeper">Deeper</a>`,
+	"/deeper":         `<p>deeper</p>`,
+	"/hidden":         `<p>hidden</p>`,
+	"/private/x":      `<p>private</p>`,
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/a", http.StatusMovedPermanently)
+			return
+		case "/file.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			fmt.Fprint(w, "%PDF")
+			return
+		}
+		content, ok := testSite[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/robots.txt" {
+			w.Header().Set("Content-Type", "text/plain")
+		} else {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Last-Modified", "Sat, 12 Feb 2022 10:00:00 GMT")
+		}
+		fmt.Fprint(w, content)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestCrawl tests crawling a site with robots.txt, robots meta tags, canonical links and redirects
+func TestCrawl(t *testing.T) {
+	server := newTestServer(t)
+	errs := make([]string, 0)
+
+	c := New()
+	c.SetHTTPClient(server.Client())
+	c.SetErrorHandler(func(pageURL string, err error) {
+		errs = append(errs, strings.TrimPrefix(pageURL, server.URL)+": "+err.Error())
+	})
+	sink := sliceSink{}
+	err := c.Crawl(context.Background(), server.URL, &sink)
+	assert.NoError(t, err)
+
+	expected := []string{"/", "/a", "/private/public", "/nofollow", "/deep", "/from-noindex", "/deeper"}
+	for i := range expected {
+		expected[i] = server.URL + expected[i]
+	}
+	assert.Equal(t, expected, sink.locs())
+	assert.Equal(t, []string{"/missing: unexpected status 404 Not Found"}, errs)
+
+	home := sink[0]
+	assert.Equal(t, time.Date(2022, 2, 12, 10, 0, 0, 0, time.UTC), home.LastMod.UTC())
+	assert.Len(t, home.Images, 1)
+	assert.Equal(t, server.URL+"/logo.png", home.Images[0].ImageLoc)
+	assert.Equal(t, "https://cdn.example.com/a.jpg", sink[1].Images[0].ImageLoc)
+}
+
+// TestCrawlLimits tests the depth and pages limits and the rate limit of Crawler
+func TestCrawlLimits(t *testing.T) {
+	server := newTestServer(t)
+
+	c := New()
+	c.SetHTTPClient(server.Client())
+	c.SetMaxDepth(1)
+	sink := sliceSink{}
+	err := c.Crawl(context.Background(), server.URL+"/", &sink)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{server.URL + "/", server.URL + "/a", server.URL + "/private/public", server.URL + "/nofollow"},
+		sink.locs())
+
+	c.SetMaxPages(2)
+	c.SetConcurrency(1)
+	c.SetDelay(20 * time.Millisecond)
+	sink = sliceSink{}
+	start := time.Now()
+	err = c.Crawl(context.Background(), server.URL, &sink)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{server.URL + "/", server.URL + "/a"}, sink.locs())
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(20*time.Millisecond))
+
+	c.SetUserAgent("Other/2.0")
+	sink = sliceSink{}
+	err = c.Crawl(context.Background(), server.URL, &sink)
+	assert.NoError(t, err)
+	assert.Empty(t, sink)
+
+	err = c.Crawl(context.Background(), "/relative", &sink)
+	assert.Error(t, err)
+}
+
+// TestRobotsTxt tests matching the paths against robots.txt rules
+func TestRobotsTxt(t *testing.T) {
+	robots, err := parseRobotsTxt(strings.NewReader(`# comment
+User-agent: Googlebot
+User-agent: smg-crawler
+Disallow: /search
+Disallow: /*.php$
+Allow: /search/about
+Crawl-delay: 1.5
+
+User-agent: *
+Disallow: /
+`), "smg-crawler/1.0 (+https://github.com/sabloger/sitemap-generator)")
+	if err != nil {
+		t.Fatal("Unable to parse robots.txt:", err)
+	}
+
+	assert.Equal(t, 1500*time.Millisecond, robots.crawlDelay)
+	assert.True(t, robots.allowed("/"))
+	assert.False(t, robots.allowed("/search?q=1"))
+	assert.True(t, robots.allowed("/search/about"))
+	assert.False(t, robots.allowed("/index.php"))
+	assert.True(t, robots.allowed("/index.php?page=1"))
+	assert.True(t, robots.allowed("/robots.txt"))
+}
+
+// TestCrawlIntoSitemap tests that the absolute image URLs of crawled pages are kept in the saved Sitemap
+func TestCrawlIntoSitemap(t *testing.T) {
+	server := newTestServer(t)
+
+	for _, hostname := range []string{"", "https://www.example.com"} {
+		storage := smg.NewMemoryStorage()
+		sm := smg.NewSitemap(false)
+		sm.SetHostname(hostname)
+		sm.SetStorage(storage)
+		sm.SetCompress(false)
+		sm.SetMaxURLsCount(1)
+		assert.NoError(t, sm.SetFilenameTemplate("{name}-{part}"))
+
+		c := New()
+		c.SetHTTPClient(server.Client())
+		err := c.Crawl(context.Background(), server.URL, sm)
+		assert.NoError(t, err)
+		_, err = sm.Save()
+		if err != nil {
+			t.Fatal("Unable to Save Sitemap:", err)
+		}
+
+		home, err := storage.ReadFile("sitemap-1.xml")
+		if err != nil {
+			t.Fatal("Unable to read sitemap:", err)
+		}
+		assert.Contains(t, string(home), "<image:image><image:loc>"+server.URL+"/logo.png</image:loc></image:image>", hostname)
+		a, err := storage.ReadFile("sitemap-2.xml")
+		if err != nil {
+			t.Fatal("Unable to read sitemap:", err)
+		}
+		assert.Contains(t, string(a), "<image:image><image:loc>https://cdn.example.com/a.jpg</image:loc></image:image>", hostname)
+	}
+}
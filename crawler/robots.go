@@ -0,0 +1,140 @@
+package crawler
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsRules contains the rules of the robots.txt group which matches the user agent.
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// robotsRule is an Allow or Disallow rule of robots.txt.
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// robotsGroup is a group of robots.txt records for one or more user agents.
+type robotsGroup struct {
+	agents []string
+	robotsRules
+}
+
+// parseRobotsTxt parses robots.txt from r and returns the rules of the group which matches
+// the product token of userAgent. The "*" group is used in case of absence of a matching group.
+func parseRobotsTxt(r io.Reader, userAgent string) (*robotsRules, error) {
+	groups := make([]*robotsGroup, 0)
+	var current *robotsGroup
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		colon := strings.IndexByte(line, ':')
+		if colon < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:colon]))
+		value := strings.TrimSpace(line[colon+1:])
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules = append(current.rules, robotsRule{pattern: value, allow: key == "allow"})
+		case "crawl-delay":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+	var matched *robotsRules
+	for _, group := range groups {
+		for _, agent := range group.agents {
+			if agent == token && token != "" {
+				return &group.robotsRules, nil
+			}
+			if agent == "*" && matched == nil {
+				matched = &group.robotsRules
+			}
+		}
+	}
+	if matched == nil {
+		matched = &robotsRules{}
+	}
+	return matched, nil
+}
+
+// allowed checks whether the path with query is allowed. The most specific
+// rule which is the longest matching pattern wins and Allow wins in case of equal lengths.
+func (r *robotsRules) allowed(path string) bool {
+	if path == "/robots.txt" {
+		return true
+	}
+	allow := true
+	length := -1
+	for _, rule := range r.rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > length || (len(rule.pattern) == length && rule.allow) {
+			allow = rule.allow
+			length = len(rule.pattern)
+		}
+	}
+	return allow
+}
+
+// matchRobotsPattern matches the path against a robots.txt pattern which
+// supports "*" wildcard and "$" end anchor.
+func matchRobotsPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if i == len(parts)-2 && anchored {
+			return strings.HasSuffix(rest, part)
+		}
+		j := strings.Index(rest, part)
+		if j < 0 {
+			return false
+		}
+		rest = rest[j+len(part):]
+	}
+	return !anchored || rest == ""
+}
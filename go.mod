@@ -2,4 +2,7 @@ module github.com/sabloger/sitemap-generator
 
 go 1.16
 
-require github.com/stretchr/testify v1.7.1
+require (
+	github.com/stretchr/testify v1.7.1
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,224 @@
+// Package htmlmeta extracts the links, images and robots meta directives of HTML documents
+// which are needed for building sitemaps from HTML pages.
+package htmlmeta
+
+import (
+	"html"
+	"io"
+	"strings"
+)
+
+// maxDocumentSize is the maximum size of a document which is read by Parse.
+const maxDocumentSize = 10 << 20
+
+// rawTextTags are the tags which their content is not parsed as HTML.
+var rawTextTags = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"textarea": true,
+	"title":    true,
+}
+
+// Document contains the extracted data of an HTML document.
+// Links and Images are the raw attribute values which must be resolved against
+// the Base or the document URL in case of empty Base.
+type Document struct {
+	Base      string
+	Canonical string
+	Links     []string
+	Images    []string
+	NoIndex   bool
+	NoFollow  bool
+}
+
+// Parse reads an HTML document from r and extracts the href of <a> tags without
+// rel="nofollow", the canonical <link>, the src of <img> tags, the <base> href and
+// the noindex and nofollow directives of <meta name="robots"> tags.
+// The parser is lenient and does not fail on malformed documents.
+func Parse(r io.Reader) (*Document, error) {
+	content, err := io.ReadAll(io.LimitReader(r, maxDocumentSize))
+	if err != nil {
+		return nil, err
+	}
+	doc := &Document{}
+	s := string(content)
+	for i := 0; i < len(s); {
+		j := strings.IndexByte(s[i:], '<')
+		if j < 0 {
+			break
+		}
+		i += j
+
+		switch {
+		case strings.HasPrefix(s[i:], "<!--"):
+			i = skipPast(s, i+4, "-->")
+			continue
+		case strings.HasPrefix(s[i:], "<!") || strings.HasPrefix(s[i:], "<?") || strings.HasPrefix(s[i:], "</"):
+			i = skipPast(s, i+2, ">")
+			continue
+		}
+
+		name, attrs, end := parseTag(s, i+1)
+		i = end
+		if name == "" {
+			continue
+		}
+		if rawTextTags[name] {
+			i = skipRawText(s, i, name)
+		}
+		doc.handleTag(name, attrs)
+	}
+	return doc, nil
+}
+
+// handleTag extracts the data of a parsed tag into doc.
+func (doc *Document) handleTag(name string, attrs map[string]string) {
+	switch name {
+	case "a", "area":
+		href, ok := attrs["href"]
+		if ok && !hasToken(attrs["rel"], "nofollow") {
+			doc.Links = append(doc.Links, href)
+		}
+	case "link":
+		if hasToken(attrs["rel"], "canonical") && doc.Canonical == "" {
+			doc.Canonical = attrs["href"]
+		}
+	case "img":
+		if src, ok := attrs["src"]; ok && src != "" {
+			doc.Images = append(doc.Images, src)
+		}
+	case "base":
+		if doc.Base == "" {
+			doc.Base = attrs["href"]
+		}
+	case "meta":
+		if strings.EqualFold(strings.TrimSpace(attrs["name"]), "robots") {
+			noIndex, noFollow := ParseRobots(attrs["content"])
+			doc.NoIndex = doc.NoIndex || noIndex
+			doc.NoFollow = doc.NoFollow || noFollow
+		}
+	}
+}
+
+// ParseRobots parses the directives of a robots meta tag or X-Robots-Tag header value.
+// The "none" directive is equal to both noindex and nofollow.
+func ParseRobots(value string) (noIndex, noFollow bool) {
+	for _, directive := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "noindex":
+			noIndex = true
+		case "nofollow":
+			noFollow = true
+		case "none":
+			noIndex, noFollow = true, true
+		}
+	}
+	return
+}
+
+// parseTag parses the name and attributes of the tag which starts at i after '<'.
+// name is empty in case of a '<' which does not start a tag.
+// returns the index after the end of tag.
+func parseTag(s string, i int) (name string, attrs map[string]string, end int) {
+	start := i
+	if i >= len(s) || !isLetter(s[i]) {
+		return "", nil, i
+	}
+	for i < len(s) && (isLetter(s[i]) || s[i] >= '0' && s[i] <= '9' || s[i] == '-') {
+		i++
+	}
+	name = strings.ToLower(s[start:i])
+	attrs = make(map[string]string)
+
+	for i < len(s) {
+		for i < len(s) && (isSpace(s[i]) || s[i] == '/') {
+			i++
+		}
+		if i >= len(s) {
+			break
+		}
+		if s[i] == '>' {
+			return name, attrs, i + 1
+		}
+
+		attrStart := i
+		for i < len(s) && !isSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+			i++
+		}
+		attr := strings.ToLower(s[attrStart:i])
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		value := ""
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isSpace(s[i]) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				quote := s[i]
+				valueEnd := strings.IndexByte(s[i+1:], quote)
+				if valueEnd < 0 {
+					value, i = s[i+1:], len(s)
+				} else {
+					value, i = s[i+1:i+1+valueEnd], i+valueEnd+2
+				}
+			} else {
+				valueStart := i
+				for i < len(s) && !isSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				value = s[valueStart:i]
+			}
+		}
+		if _, ok := attrs[attr]; !ok && attr != "" {
+			attrs[attr] = html.UnescapeString(value)
+		}
+	}
+	return name, attrs, len(s)
+}
+
+// skipPast returns the index after the first occurrence of sep in s[i:] or len(s) in case of absence.
+func skipPast(s string, i int, sep string) int {
+	if i > len(s) {
+		return len(s)
+	}
+	j := strings.Index(s[i:], sep)
+	if j < 0 {
+		return len(s)
+	}
+	return i + j + len(sep)
+}
+
+// skipRawText returns the index after the closing tag of name which starts after i.
+func skipRawText(s string, i int, name string) int {
+	for i < len(s) {
+		j := strings.Index(s[i:], "</")
+		if j < 0 {
+			break
+		}
+		i += j + 2
+		if len(s)-i >= len(name) && strings.EqualFold(s[i:i+len(name)], name) {
+			return skipPast(s, i, ">")
+		}
+	}
+	return len(s)
+}
+
+// hasToken checks whether the space separated list contains the token case-insensitively.
+func hasToken(list, token string) bool {
+	for _, t := range strings.Fields(list) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
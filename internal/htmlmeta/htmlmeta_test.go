@@ -0,0 +1,39 @@
+package htmlmeta
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParse tests extracting the links, images and robots directives of a malformed document
+func TestParse(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`<!DOCTYPE html>
+<HTML><head>
+<title>a < b</title>
+<base href="https://www.example.com/blog/">
+<link rel="alternate canonical" href="/blog/post?a=1&amp;b=2">
+<META NAME="Robots" CONTENT="NoFollow, noarchive">
+<script>if (a < b) { document.write('<a href="/script">'); }</script>
+<!-- <a href="/comment"> -->
+</head><body>
+<a href=/unquoted>x</a> <a class=x href='/single'>y<a href="/sponsored" rel="sponsored nofollow">
+<img src="/image.jpg" alt="1 < 2"><img alt="no src"><br/>
+<p>1 < 2 and <3</p>
+<a href="/last"`))
+	if err != nil {
+		t.Fatal("Unable to parse:", err)
+	}
+
+	assert.Equal(t, "https://www.example.com/blog/", doc.Base)
+	assert.Equal(t, "/blog/post?a=1&b=2", doc.Canonical)
+	assert.Equal(t, []string{"/unquoted", "/single", "/last"}, doc.Links)
+	assert.Equal(t, []string{"/image.jpg"}, doc.Images)
+	assert.False(t, doc.NoIndex)
+	assert.True(t, doc.NoFollow)
+
+	noIndex, noFollow := ParseRobots("none")
+	assert.True(t, noIndex)
+	assert.True(t, noFollow)
+}
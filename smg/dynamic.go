@@ -0,0 +1,436 @@
+package smg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDynamicTTL      time.Duration = 10 * time.Minute
+	defaultDynamicStaleTTL time.Duration = time.Hour
+	defaultDynamicTimeout  time.Duration = 30 * time.Second
+	dynamicIndexKey        int           = 0
+)
+
+// CountFunc returns the total number of URLs of a DynamicHandler.
+type CountFunc func(ctx context.Context) (int, error)
+
+// PartFunc yields the URLs of a part of DynamicHandler by calling add for each of them.
+type PartFunc func(ctx context.Context, part DynamicPart, add func(u *SitemapLoc) error) error
+
+// DynamicPart describes a part of DynamicHandler which is requested from PartFunc.
+// Number is 1-based and Offset is the index of the first URL of the part
+// which is (Number-1)*Limit; Limit is the maximum # of URLs in each part.
+type DynamicPart struct {
+	Number int
+	Offset int
+	Limit  int
+}
+
+// DynamicHandler is an http.Handler which generates the SitemapIndex and it's Sitemap parts
+// lazily on request. The SitemapIndex is computed from the CountFunc and each part is
+// generated from the PartFunc using the same splitting limits as Sitemap.
+// Generated files are cached for the TTL and afterwards are served stale while being
+// revalidated in background for the stale TTL. Each generation is canceled after the
+// generation timeout, then the stale file is kept or 503 status is served in case of
+// absence of a cached file.
+// Options are used like SitemapIndex: Name is the filename of SitemapIndex and the parts
+// are named by appending their number to it, Hostname and ServerURI make the URLs of parts.
+type DynamicHandler struct {
+	Options
+	countFunc    CountFunc
+	partFunc     PartFunc
+	maxURLsCount int
+	ttl          time.Duration
+	staleTTL     time.Duration
+	timeout      time.Duration
+	maxAge       time.Duration
+	entries      map[int]*dynamicEntry
+	mutex        sync.Mutex
+}
+
+// dynamicEntry is a cached generated file of DynamicHandler.
+type dynamicEntry struct {
+	file      *servedFile
+	content   []byte
+	partCount int
+	expires   time.Time
+	done      chan struct{}
+	err       error
+	refresh   bool
+}
+
+// NewDynamicHandler builds and returns a new DynamicHandler using the count and part functions.
+func NewDynamicHandler(count CountFunc, part PartFunc, prettyPrint bool) *DynamicHandler {
+	d := &DynamicHandler{
+		countFunc:    count,
+		partFunc:     part,
+		maxURLsCount: MaxURLsCount,
+		ttl:          defaultDynamicTTL,
+		staleTTL:     defaultDynamicStaleTTL,
+		timeout:      defaultDynamicTimeout,
+		maxAge:       defaultMaxAge,
+		entries:      make(map[int]*dynamicEntry),
+	}
+	d.Name = "sitemap"
+	d.Compress = true
+	d.prettyPrint = prettyPrint
+	return d
+}
+
+// SetSitemapIndexName sets the filename of SitemapIndex without extension.
+func (d *DynamicHandler) SetSitemapIndexName(name string) {
+	d.Name = name
+}
+
+// SetHostname sets the Hostname which is prepended to all URLs.
+func (d *DynamicHandler) SetHostname(hostname string) {
+	d.Hostname = hostname
+}
+
+// SetServerURI sets the ServerURI which is used for making the URLs of parts in SitemapIndex.
+func (d *DynamicHandler) SetServerURI(serverURI string) {
+	d.ServerURI = serverURI
+}
+
+// SetCompress sets the Compress option to be either enabled or disabled for the generated files.
+func (d *DynamicHandler) SetCompress(compress bool) {
+	d.Compress = compress
+}
+
+// SetLastModPrecision sets the W3C Datetime precision of lastmod values.
+func (d *DynamicHandler) SetLastModPrecision(precision TimePrecision) {
+	d.lastModPrecision = precision
+}
+
+// SetLastModLocation sets the timezone of lastmod values.
+func (d *DynamicHandler) SetLastModLocation(loc *time.Location) {
+	d.lastModLocation = loc
+}
+
+// SetMaxURLsCount sets the maximum # of URLs for each part
+// which must be between 1 and 50,000 of sitemaps.org protocol.
+func (d *DynamicHandler) SetMaxURLsCount(maxURLsCount int) error {
+	if maxURLsCount < 1 || maxURLsCount > MaxURLsCount {
+		return fmt.Errorf("max URLs count %d must be between 1 and %d", maxURLsCount, MaxURLsCount)
+	}
+	d.maxURLsCount = maxURLsCount
+	return nil
+}
+
+// SetTTL sets the duration which generated files are served from cache. Default is 10 minutes.
+func (d *DynamicHandler) SetTTL(ttl time.Duration) {
+	d.ttl = ttl
+}
+
+// SetStaleTTL sets the duration after TTL which stale files are served while they
+// are being regenerated in background. Default is one hour.
+func (d *DynamicHandler) SetStaleTTL(staleTTL time.Duration) {
+	d.staleTTL = staleTTL
+}
+
+// SetGenerationTimeout sets the duration after which generating a file is canceled
+// using the context of CountFunc and PartFunc. Default is 30 seconds.
+func (d *DynamicHandler) SetGenerationTimeout(timeout time.Duration) error {
+	if timeout <= 0 {
+		return fmt.Errorf("generation timeout %s must be positive", timeout)
+	}
+	d.timeout = timeout
+	return nil
+}
+
+// SetClock sets the clock which is used instead of time.Now for the cache expiry and modification times.
+func (d *DynamicHandler) SetClock(now func() time.Time) {
+	d.now = now
+}
+
+// SetLogger sets the Logger of DynamicHandler which is silent by default.
+func (d *DynamicHandler) SetLogger(logger Logger) {
+	d.log = logger
+}
+
+// SetMaxAge sets the max-age of Cache-Control header. Default is one hour.
+func (d *DynamicHandler) SetMaxAge(maxAge time.Duration) {
+	d.maxAge = maxAge
+}
+
+// ServeHTTP serves the SitemapIndex or a part. Implements http.Handler interface.
+func (d *DynamicHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if strings.HasSuffix(name, gzipExt) && !d.Compress {
+		http.NotFound(w, r)
+		return
+	}
+	key, ok := d.partKey(strings.TrimSuffix(name, gzipExt))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	index, err := d.entry(r.Context(), dynamicIndexKey)
+	if err != nil {
+		d.serveError(w, err)
+		return
+	}
+	entry := index
+	if key != dynamicIndexKey {
+		if key > index.partCount {
+			http.NotFound(w, r)
+			return
+		}
+		entry, err = d.entry(r.Context(), key)
+		if err != nil {
+			d.serveError(w, err)
+			return
+		}
+	}
+
+	file := *entry.file
+	file.name = name
+	file.content = bytes.NewReader(entry.content)
+	serveFile(w, r, &file, fmt.Sprintf("public, max-age=%d", int(d.maxAge.Seconds())))
+}
+
+// serveError writes the status of a failed generation which is 503 for
+// the timed out generations and 500 for the others.
+func (d *DynamicHandler) serveError(w http.ResponseWriter, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(d.timeout.Seconds())+1))
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// partKey returns the cache key of the requested filename which is
+// zero for SitemapIndex and the part number for parts.
+func (d *DynamicHandler) partKey(name string) (int, bool) {
+	if !strings.HasSuffix(name, fileExt) {
+		return 0, false
+	}
+	name = strings.TrimSuffix(name, fileExt)
+	if name == d.Name {
+		return dynamicIndexKey, true
+	}
+	if !strings.HasPrefix(name, d.Name) {
+		return 0, false
+	}
+	number, err := strconv.Atoi(strings.TrimPrefix(name, d.Name))
+	if err != nil || number < 1 || strconv.Itoa(number) != strings.TrimPrefix(name, d.Name) {
+		return 0, false
+	}
+	return number, true
+}
+
+// entry returns the cached entry of key. Missing and expired entries are generated
+// in background and the waiting callers share the result, so each caller gives up
+// on its own ctx without failing the others. Stale entries are returned while
+// they are regenerated in background.
+func (d *DynamicHandler) entry(ctx context.Context, key int) (*dynamicEntry, error) {
+	now := d.Now()
+
+	d.mutex.Lock()
+	entry, ok := d.entries[key]
+	if ok && entry.file != nil {
+		if now.Before(entry.expires) {
+			d.mutex.Unlock()
+			return entry, nil
+		}
+		if now.Before(entry.expires.Add(d.staleTTL)) {
+			if !entry.refresh {
+				entry.refresh = true
+				go d.generate(key)
+			}
+			d.mutex.Unlock()
+			return entry, nil
+		}
+	}
+	if !ok || entry.file != nil {
+		entry = &dynamicEntry{done: make(chan struct{})}
+		d.entries[key] = entry
+		d.mutex.Unlock()
+		go d.fill(key, entry)
+	} else {
+		d.mutex.Unlock()
+	}
+
+	select {
+	case <-entry.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if entry.err != nil {
+		return nil, entry.err
+	}
+	return entry, nil
+}
+
+// generate regenerates the entry of key in background and replaces the stale entry.
+func (d *DynamicHandler) generate(key int) {
+	entry := &dynamicEntry{done: make(chan struct{})}
+	d.fill(key, entry)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if entry.err != nil {
+		d.logger().Warn("failed to regenerate stale sitemap", "key", key, "error", entry.err)
+		// keeps serving the stale entry and retries on the next request
+		if stale, ok := d.entries[key]; ok {
+			stale.refresh = false
+		}
+		return
+	}
+	d.entries[key] = entry
+}
+
+// fill generates the file of key into the entry and closes its done channel.
+// The generation is given up after the generation timeout even if
+// CountFunc or PartFunc ignore the cancellation of their context.
+func (d *DynamicHandler) fill(key int, entry *dynamicEntry) {
+	defer close(entry.done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+	type result struct {
+		content   []byte
+		partCount int
+		err       error
+	}
+	results := make(chan result, 1)
+	go func() {
+		content, partCount, err := d.build(ctx, key)
+		results <- result{content, partCount, err}
+	}()
+
+	var content []byte
+	var partCount int
+	var err error
+	select {
+	case res := <-results:
+		content, partCount, err = res.content, res.partCount, res.err
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err == nil && d.Compress {
+		content, err = gzipBytes(content)
+	}
+	now := d.Now()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if err != nil {
+		d.logger().Error("failed to generate sitemap", "key", key, "error", err)
+		entry.err = err
+		// failed entries are not cached
+		if d.entries[key] == entry {
+			delete(d.entries, key)
+		}
+		return
+	}
+	entry.content = content
+	entry.file = &servedFile{
+		compressed: d.Compress,
+		modTime:    now.UTC(),
+		etag:       contentETag(content),
+	}
+	entry.partCount = partCount
+	entry.expires = now.Add(d.ttl)
+}
+
+// build builds the content of key. The panics of CountFunc and PartFunc are returned as errors.
+func (d *DynamicHandler) build(ctx context.Context, key int) (content []byte, partCount int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			content, partCount, err = nil, 0, fmt.Errorf("panic while generating sitemap: %v", r)
+		}
+	}()
+	if key == dynamicIndexKey {
+		return d.buildIndex(ctx)
+	}
+	content, err = d.buildPart(ctx, key)
+	return content, 0, err
+}
+
+// buildIndex builds the SitemapIndex content using the CountFunc.
+func (d *DynamicHandler) buildIndex(ctx context.Context) ([]byte, int, error) {
+	count, err := d.countFunc(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	partCount := (count + d.maxURLsCount - 1) / d.maxURLsCount
+
+	smi := NewSitemapIndex(d.prettyPrint)
+	smi.Options = d.Options
+	for i := 1; i <= partCount; i++ {
+		loc, err := publicURL(d.Hostname, d.ServerURI, d.partFilename(i))
+		if err != nil {
+			return nil, 0, err
+		}
+		smi.Add(&SitemapIndexLoc{Loc: loc})
+	}
+
+	buf := bytes.Buffer{}
+	_, err = smi.WriteTo(&buf)
+	if err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), partCount, nil
+}
+
+// buildPart builds the content of the numbered part using the PartFunc.
+func (d *DynamicHandler) buildPart(ctx context.Context, number int) ([]byte, error) {
+	sm := NewSitemap(d.prettyPrint)
+	sm.SetHostname(d.Hostname)
+	sm.SetLastModPrecision(d.lastModPrecision)
+	sm.SetLastModLocation(d.lastModLocation)
+	sm.SetMaxURLsCount(d.maxURLsCount)
+
+	part := DynamicPart{
+		Number: number,
+		Offset: (number - 1) * d.maxURLsCount,
+		Limit:  d.maxURLsCount,
+	}
+	err := d.partFunc(ctx, part, func(u *SitemapLoc) error {
+		if sm.NextSitemap != nil || sm.GetURLsCount() >= d.maxURLsCount {
+			return errors.New("part exceeds the sitemap limits")
+		}
+		return sm.Add(u)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if sm.NextSitemap != nil {
+		return nil, errors.New("part exceeds the sitemap limits")
+	}
+	sm.Finalize()
+
+	buf := bytes.Buffer{}
+	_, err = sm.WriteTo(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// partFilename returns the filename of the numbered part which is referenced in SitemapIndex.
+func (d *DynamicHandler) partFilename(number int) string {
+	filename := d.Name + strconv.Itoa(number) + fileExt
+	if d.Compress {
+		filename += gzipExt
+	}
+	return filename
+}
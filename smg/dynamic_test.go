@@ -0,0 +1,211 @@
+package smg
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestDynamicHandler(total int, partCalls *int32) *DynamicHandler {
+	count := func(ctx context.Context) (int, error) {
+		return total, nil
+	}
+	part := func(ctx context.Context, part DynamicPart, add func(u *SitemapLoc) error) error {
+		atomic.AddInt32(partCalls, 1)
+		for i := part.Offset; i < part.Offset+part.Limit && i < total; i++ {
+			err := add(&SitemapLoc{Loc: fmt.Sprintf("/item/%d", i)})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	d := NewDynamicHandler(count, part, false)
+	d.SetHostname(baseURL)
+	_ = d.SetMaxURLsCount(2)
+	return d
+}
+
+// TestDynamicHandler tests the lazy generation of SitemapIndex and parts
+func TestDynamicHandler(t *testing.T) {
+	var partCalls int32
+	d := newTestDynamicHandler(5, &partCalls)
+
+	w := serveRequest(d, http.MethodGet, "/sitemap.xml", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var sitemapIndex SitemapIndexXml
+	err := xml.Unmarshal(w.Body.Bytes(), &sitemapIndex)
+	if err != nil {
+		t.Fatal("Unable to unmarhsall sitemap byte array into xml: ", err)
+	}
+	assert.Len(t, sitemapIndex.Sitemaps, 3)
+	assert.Equal(t, baseURL+"/sitemap3.xml.gz", sitemapIndex.Sitemaps[2].Loc)
+
+	w = serveRequest(d, http.MethodGet, "/sitemap3.xml.gz", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serveRequest(d, http.MethodGet, "/sitemap3.xml", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var urlSet UrlSet
+	err = xml.Unmarshal(w.Body.Bytes(), &urlSet)
+	if err != nil {
+		t.Fatal("Unable to unmarhsall sitemap byte array into xml: ", err)
+	}
+	assert.Len(t, urlSet.Urls, 1)
+	assert.Equal(t, baseURL+"/item/4", urlSet.Urls[0].Loc)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&partCalls))
+
+	assert.Equal(t, http.StatusNotFound, serveRequest(d, http.MethodGet, "/sitemap4.xml", nil).Code)
+	assert.Equal(t, http.StatusNotFound, serveRequest(d, http.MethodGet, "/sitemap01.xml", nil).Code)
+	assert.Equal(t, http.StatusNotFound, serveRequest(d, http.MethodGet, "/other.xml", nil).Code)
+}
+
+// TestDynamicHandlerStaleWhileRevalidate tests that expired parts are served stale and regenerated in background
+func TestDynamicHandlerStaleWhileRevalidate(t *testing.T) {
+	var partCalls int32
+	d := newTestDynamicHandler(2, &partCalls)
+	d.SetCompress(false)
+	d.SetTTL(0)
+
+	w := serveRequest(d, http.MethodGet, "/sitemap1.xml", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&partCalls))
+
+	w = serveRequest(d, http.MethodGet, "/sitemap1.xml", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&partCalls) >= 2
+	}, time.Second, 10*time.Millisecond)
+
+	d.SetStaleTTL(0)
+	w = serveRequest(d, http.MethodGet, "/sitemap1.xml", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&partCalls), int32(3))
+}
+
+// TestDynamicHandlerPanic tests that the panics of CountFunc and PartFunc fail only the current generation
+func TestDynamicHandlerPanic(t *testing.T) {
+	var countCalls, partCalls int32
+	count := func(ctx context.Context) (int, error) {
+		if atomic.AddInt32(&countCalls, 1) == 1 {
+			panic("count failed")
+		}
+		return 2, nil
+	}
+	part := func(ctx context.Context, part DynamicPart, add func(u *SitemapLoc) error) error {
+		if atomic.AddInt32(&partCalls, 1) > 1 {
+			panic("part failed")
+		}
+		return add(&SitemapLoc{Loc: "/item"})
+	}
+	d := NewDynamicHandler(count, part, false)
+	d.SetHostname(baseURL)
+	d.SetCompress(false)
+
+	assert.Equal(t, http.StatusInternalServerError, serveRequest(d, http.MethodGet, "/sitemap.xml", nil).Code)
+	assert.Equal(t, http.StatusOK, serveRequest(d, http.MethodGet, "/sitemap.xml", nil).Code)
+
+	// the stale part is kept when it's regeneration panics
+	d.SetTTL(0)
+	assert.Equal(t, http.StatusOK, serveRequest(d, http.MethodGet, "/sitemap1.xml", nil).Code)
+	assert.Equal(t, http.StatusOK, serveRequest(d, http.MethodGet, "/sitemap1.xml", nil).Code)
+	assert.Eventually(t, func() bool {
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+		return atomic.LoadInt32(&partCalls) == 2 && !d.entries[1].refresh
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, http.StatusOK, serveRequest(d, http.MethodGet, "/sitemap1.xml", nil).Code)
+}
+
+// TestDynamicHandlerCanceledRequest tests that a canceled request does not fail the other requests of the same file
+func TestDynamicHandlerCanceledRequest(t *testing.T) {
+	release := make(chan struct{})
+	count := func(ctx context.Context) (int, error) {
+		<-release
+		return 1, ctx.Err()
+	}
+	d := NewDynamicHandler(count, nil, false)
+	d.SetCompress(false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		d.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil).WithContext(ctx))
+		canceled <- w.Code
+	}()
+	waiting := make(chan int)
+	go func() {
+		assert.Eventually(t, func() bool {
+			d.mutex.Lock()
+			defer d.mutex.Unlock()
+			return d.entries[dynamicIndexKey] != nil
+		}, time.Second, time.Millisecond)
+		waiting <- serveRequest(d, http.MethodGet, "/sitemap.xml", nil).Code
+	}()
+
+	cancel()
+	assert.Equal(t, http.StatusInternalServerError, <-canceled)
+	close(release)
+	assert.Equal(t, http.StatusOK, <-waiting)
+}
+
+// TestDynamicHandlerMaxURLsCount tests the validation of max URLs count
+func TestDynamicHandlerMaxURLsCount(t *testing.T) {
+	d := NewDynamicHandler(nil, nil, false)
+	assert.Error(t, d.SetMaxURLsCount(0))
+	assert.Error(t, d.SetMaxURLsCount(50001))
+	assert.NoError(t, d.SetMaxURLsCount(1))
+}
+
+// TestDynamicHandlerGenerationTimeout tests that the timed out generations keep the stale files or fail with 503 status
+func TestDynamicHandlerGenerationTimeout(t *testing.T) {
+	var slow int32
+	release := make(chan struct{})
+	defer close(release)
+	count := func(ctx context.Context) (int, error) {
+		if atomic.LoadInt32(&slow) == 1 {
+			// ignores the cancellation of ctx
+			<-release
+		}
+		return 1, nil
+	}
+	d := NewDynamicHandler(count, nil, false)
+	d.SetCompress(false)
+	assert.Error(t, d.SetGenerationTimeout(0))
+	assert.NoError(t, d.SetGenerationTimeout(10*time.Millisecond))
+
+	now := time.Date(2022, 2, 12, 16, 29, 46, 0, time.UTC)
+	var elapsed int64
+	d.SetClock(func() time.Time { return now.Add(time.Duration(atomic.LoadInt64(&elapsed))) })
+	w := serveRequest(d, http.MethodGet, "/sitemap.xml", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+
+	// the stale file is kept when it's regeneration times out
+	atomic.StoreInt32(&slow, 1)
+	atomic.StoreInt64(&elapsed, int64(defaultDynamicTTL))
+	w = serveRequest(d, http.MethodGet, "/sitemap.xml", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+	assert.Eventually(t, func() bool {
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+		return !d.entries[dynamicIndexKey].refresh
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, etag, serveRequest(d, http.MethodGet, "/sitemap.xml", nil).Header().Get("ETag"))
+
+	// 503 status in case of absence of a stale file
+	atomic.StoreInt64(&elapsed, int64(2*defaultDynamicTTL+defaultDynamicStaleTTL))
+	w = serveRequest(d, http.MethodGet, "/sitemap.xml", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+}
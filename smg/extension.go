@@ -0,0 +1,159 @@
+package smg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// xmlNamespace is a registered XML namespace which is declared on the urlset tag
+// of the Sitemap files which use it.
+type xmlNamespace struct {
+	prefix string
+	uri    string
+}
+
+// reservedNamespacePrefixes are the prefixes which are declared by the package.
+var reservedNamespacePrefixes = map[string]bool{"image": true}
+
+// namespacePrefix matches the valid XML namespace prefixes.
+var namespacePrefix = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// xmlExtension is an encoded extension element of SitemapLoc whose names use
+// the prefixes of registered namespaces.
+type xmlExtension []xml.Token
+
+// MarshalXML writes the tokens of extension, so they are indented as the other elements of <url>.
+func (x xmlExtension) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	for _, token := range x {
+		err := e.EncodeToken(token)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNamespace validates the prefix and uri of a namespace which is registered in addition
+// to the namespaces. It reports whether the same namespace is already registered.
+func checkNamespace(namespaces []xmlNamespace, prefix, uri string) (bool, error) {
+	if !namespacePrefix.MatchString(prefix) || strings.HasPrefix(strings.ToLower(prefix), "xml") || reservedNamespacePrefixes[prefix] {
+		return false, fmt.Errorf("invalid namespace prefix %q", prefix)
+	}
+	u, err := url.Parse(uri)
+	if err != nil || !u.IsAbs() || strings.ContainsAny(uri, "\"<>&") {
+		return false, fmt.Errorf("invalid namespace URI %q of prefix %s", uri, prefix)
+	}
+	for _, ns := range namespaces {
+		if ns.prefix == prefix {
+			if ns.uri == uri {
+				return true, nil
+			}
+			return false, fmt.Errorf("namespace prefix %s is registered with another URI %s", prefix, ns.uri)
+		}
+	}
+	return false, nil
+}
+
+// lookupNamespace returns the prefix of the registered namespace which
+// has the space as it's URI or prefix.
+func (o *Options) lookupNamespace(space string) (string, bool) {
+	for _, ns := range o.namespaces {
+		if ns.uri == space || ns.prefix == space {
+			return ns.prefix, true
+		}
+	}
+	return "", false
+}
+
+// namespaceDeclarations returns the xmlns attributes of the prefixes in order of registration.
+func (o *Options) namespaceDeclarations(prefixes map[string]bool) string {
+	declarations := ""
+	for _, ns := range o.namespaces {
+		if prefixes[ns.prefix] {
+			declarations += ` xmlns:` + ns.prefix + `="` + ns.uri + `"`
+		}
+	}
+	return declarations
+}
+
+// encodeExtensions marshals the extensions and converts their element and attribute names to
+// the prefixes of registered namespaces. The namespace of a name may be the URI or the prefix.
+// The xmlns attributes, comments, processing instructions and directives are dropped.
+// It returns the encoded extensions and the set of used prefixes.
+func (o *Options) encodeExtensions(extensions []xml.Marshaler) ([]xmlExtension, map[string]bool, error) {
+	if len(extensions) == 0 {
+		return nil, nil, nil
+	}
+	encoded := make([]xmlExtension, 0, len(extensions))
+	prefixes := make(map[string]bool)
+	qualify := func(name xml.Name, attr bool) (xml.Name, error) {
+		if name.Space == "" && attr {
+			return name, nil
+		}
+		prefix, ok := o.lookupNamespace(name.Space)
+		if !ok {
+			return name, fmt.Errorf("namespace %q of extension name %s is not registered", name.Space, name.Local)
+		}
+		prefixes[prefix] = true
+		return xml.Name{Local: prefix + ":" + name.Local}, nil
+	}
+
+	for _, extension := range extensions {
+		if extension == nil {
+			continue
+		}
+		var buf bytes.Buffer
+		err := xml.NewEncoder(&buf).Encode(extension)
+		if err != nil {
+			return nil, nil, err
+		}
+		var tokens xmlExtension
+		decoder := xml.NewDecoder(&buf)
+		for {
+			token, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				start := xml.StartElement{}
+				start.Name, err = qualify(t.Name, false)
+				if err != nil {
+					return nil, nil, err
+				}
+				for _, attr := range t.Attr {
+					if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+						continue
+					}
+					attr.Name, err = qualify(attr.Name, true)
+					if err != nil {
+						return nil, nil, err
+					}
+					start.Attr = append(start.Attr, attr)
+				}
+				tokens = append(tokens, start)
+			case xml.EndElement:
+				end := xml.EndElement{}
+				end.Name, err = qualify(t.Name, false)
+				if err != nil {
+					return nil, nil, err
+				}
+				tokens = append(tokens, end)
+			case xml.CharData:
+				tokens = append(tokens, t.Copy())
+			}
+		}
+		if len(tokens) > 0 {
+			encoded = append(encoded, tokens)
+		}
+	}
+	return encoded, prefixes, nil
+}
@@ -0,0 +1,221 @@
+package smg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	pageMapNamespace = "http://www.google.com/schemas/sitemap-pagemap/1.0"
+	partnerNamespace = "https://partner.example.com/schemas/1.0"
+)
+
+// pageMap is a PageMap extension which uses the namespace URI in it's names.
+type pageMap struct {
+	Type  string
+	Title string
+}
+
+func (p *pageMap) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	pageMap := xml.StartElement{Name: xml.Name{Space: pageMapNamespace, Local: "PageMap"}}
+	dataObject := xml.StartElement{
+		Name: xml.Name{Space: pageMapNamespace, Local: "DataObject"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "type"}, Value: p.Type}},
+	}
+	attribute := xml.StartElement{
+		Name: xml.Name{Space: pageMapNamespace, Local: "Attribute"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "name"}, Value: "title"}},
+	}
+	for _, token := range []xml.Token{
+		pageMap, dataObject, attribute, xml.CharData(p.Title),
+		attribute.End(), dataObject.End(), pageMap.End(),
+	} {
+		err := e.EncodeToken(token)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// partnerRating is an extension which uses the prefix in it's names.
+type partnerRating struct {
+	XMLName xml.Name `xml:"partner:rating"`
+	Value   int      `xml:",chardata"`
+}
+
+func (r *partnerRating) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type rating partnerRating
+	return e.Encode((*rating)(r))
+}
+
+// urlsetNamespaces returns the namespaces except image which are declared on the urlset tag of content.
+func urlsetNamespaces(t *testing.T, content []byte) []string {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			t.Fatal("urlset tag is not found")
+		}
+		if err != nil {
+			t.Fatal("Unable to decode sitemap:", err)
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "urlset" {
+			var namespaces []string
+			for _, attr := range start.Attr {
+				if attr.Name.Space == "xmlns" && attr.Name.Local != "image" {
+					namespaces = append(namespaces, attr.Name.Local+"="+attr.Value)
+				}
+			}
+			return namespaces
+		}
+	}
+}
+
+// TestSitemapExtensions tests that the Extensions are encoded using the registered prefixes and
+// only the namespaces which are used in each part are declared
+func TestSitemapExtensions(t *testing.T) {
+	storage := NewMemoryStorage()
+	sm := NewSitemap(true)
+	sm.SetHostname(baseURL)
+	sm.SetStorage(storage)
+	sm.SetCompress(false)
+	assert.NoError(t, sm.SetFilenameTemplate("{name}-{part}"))
+	sm.SetMaxURLsCount(2)
+	assert.NoError(t, sm.RegisterNamespace("pagemap", pageMapNamespace))
+	assert.NoError(t, sm.RegisterNamespace("partner", partnerNamespace))
+
+	for _, loc := range []*SitemapLoc{
+		{Loc: "/a", Extensions: []xml.Marshaler{&pageMap{Type: "document", Title: "A & B"}}},
+		{Loc: "/b"},
+		{Loc: "/c", Extensions: []xml.Marshaler{&partnerRating{Value: 5}, &pageMap{Type: "document", Title: "C"}}},
+		{Loc: "/d"},
+		{Loc: "/e"},
+	} {
+		assert.NoError(t, sm.Add(loc))
+	}
+	filenames, err := sm.Save()
+	if err != nil {
+		t.Fatal("Unable to Save Sitemap:", err)
+	}
+	assert.Equal(t, []string{"sitemap-1.xml", "sitemap-2.xml", "sitemap-3.xml"}, filenames)
+
+	expected := map[string][]string{
+		"sitemap-1.xml": {"pagemap=" + pageMapNamespace},
+		"sitemap-2.xml": {"pagemap=" + pageMapNamespace, "partner=" + partnerNamespace},
+		"sitemap-3.xml": nil,
+	}
+	for filename, namespaces := range expected {
+		content, err := storage.ReadFile(filename)
+		if err != nil {
+			t.Fatal("Unable to read sitemap:", err)
+		}
+		assert.Equal(t, namespaces, urlsetNamespaces(t, content), filename)
+		assert.Len(t, content, sizeOf(t, sm, filename), filename)
+	}
+
+	content, err := storage.ReadFile("sitemap-1.xml")
+	if err != nil {
+		t.Fatal("Unable to read sitemap:", err)
+	}
+	assert.Contains(t, string(content), "  <pagemap:PageMap>\n"+
+		"    <pagemap:DataObject type=\"document\">\n"+
+		"      <pagemap:Attribute name=\"title\">A &amp; B</pagemap:Attribute>\n"+
+		"    </pagemap:DataObject>\n"+
+		"  </pagemap:PageMap>\n"+
+		"</url>")
+
+	// the names are resolved to the registered namespaces
+	var urlset struct {
+		URLs []struct {
+			Loc     string `xml:"loc"`
+			Rating  int    `xml:"https://partner.example.com/schemas/1.0 rating"`
+			PageMap struct {
+				Title string `xml:"http://www.google.com/schemas/sitemap-pagemap/1.0 DataObject>Attribute"`
+			} `xml:"http://www.google.com/schemas/sitemap-pagemap/1.0 PageMap"`
+		} `xml:"url"`
+	}
+	content, err = storage.ReadFile("sitemap-2.xml")
+	if err != nil {
+		t.Fatal("Unable to read sitemap:", err)
+	}
+	assert.NoError(t, xml.Unmarshal(content, &urlset))
+	assert.Len(t, urlset.URLs, 2)
+	assert.Equal(t, 5, urlset.URLs[0].Rating)
+	assert.Equal(t, "C", urlset.URLs[0].PageMap.Title)
+}
+
+// sizeOf returns the size of the part of sm which is saved as filename.
+func sizeOf(t *testing.T, sm *Sitemap, filename string) int {
+	for part := sm; part != nil; part = part.NextSitemap {
+		for _, file := range part.savedFiles {
+			if file.filename == filename {
+				return part.fileSize()
+			}
+		}
+	}
+	t.Fatal("part is not found:", filename)
+	return 0
+}
+
+// TestSitemapExtensionsErrors tests the errors of invalid namespaces and unregistered Extensions
+func TestSitemapExtensionsErrors(t *testing.T) {
+	sm := NewSitemap(false)
+	assert.Error(t, sm.RegisterNamespace("image", pageMapNamespace))
+	assert.Error(t, sm.RegisterNamespace("pagemap", "pagemap"))
+	assert.NoError(t, sm.RegisterNamespace("pagemap", pageMapNamespace))
+	assert.NoError(t, sm.RegisterNamespace("pagemap", pageMapNamespace))
+	assert.Error(t, sm.RegisterNamespace("pagemap", partnerNamespace))
+	sm.SetMaxURLsCount(1)
+
+	assert.NoError(t, sm.Add(&SitemapLoc{Loc: "/a", Extensions: []xml.Marshaler{&pageMap{Title: "A"}}}))
+	// the error of a split Sitemap is returned and the URL is not added
+	err := sm.Add(&SitemapLoc{Loc: "/b", Extensions: []xml.Marshaler{&partnerRating{Value: 1}}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `namespace "partner" of extension name rating is not registered`)
+	assert.Equal(t, 0, sm.NextSitemap.GetURLsCount())
+
+	assert.NoError(t, sm.RegisterNamespace("partner", partnerNamespace))
+	assert.NoError(t, sm.Add(&SitemapLoc{Loc: "/b", Extensions: []xml.Marshaler{&partnerRating{Value: 1}}}))
+	assert.Equal(t, 1, sm.NextSitemap.GetURLsCount())
+	assert.Equal(t, []string{"partner=" + partnerNamespace}, urlsetNamespaces(t, sm.NextSitemap.header()))
+}
+
+// TestSitemapIndexRegisterNamespace tests that the namespaces are registered for existing and new Sitemaps
+func TestSitemapIndexRegisterNamespace(t *testing.T) {
+	smi := NewSitemapIndex(false)
+	sm1 := smi.NewSitemap()
+	assert.NoError(t, smi.RegisterNamespace("pagemap", pageMapNamespace))
+	sm2 := smi.NewSitemap()
+	assert.Error(t, smi.RegisterNamespace("pagemap", partnerNamespace))
+
+	for _, sm := range []*Sitemap{sm1, sm2} {
+		assert.NoError(t, sm.Add(&SitemapLoc{Loc: "/a", Extensions: []xml.Marshaler{&pageMap{Title: "A"}}}))
+		assert.Equal(t, []string{"pagemap=" + pageMapNamespace}, urlsetNamespaces(t, sm.header()))
+	}
+	assert.NoError(t, sm1.RegisterNamespace("partner", partnerNamespace))
+	assert.Len(t, sm2.namespaces, 1)
+}
+
+// TestSitemapExtensionsMaxFileSize tests that the namespace declarations are counted in the max file size
+func TestSitemapExtensionsMaxFileSize(t *testing.T) {
+	loc := func() *SitemapLoc {
+		return &SitemapLoc{Loc: "/a", Extensions: []xml.Marshaler{&pageMap{Title: "A"}}}
+	}
+	sm := NewSitemap(false)
+	assert.NoError(t, sm.RegisterNamespace("pagemap", pageMapNamespace))
+	assert.NoError(t, sm.Add(loc()))
+	size := sm.fileSize()
+
+	sm = NewSitemap(false)
+	assert.NoError(t, sm.RegisterNamespace("pagemap", pageMapNamespace))
+	assert.NoError(t, sm.SetMaxFileSize(size-1))
+	assert.Error(t, sm.Add(loc()))
+	assert.NoError(t, sm.SetMaxFileSize(size))
+	assert.NoError(t, sm.Add(loc()))
+	assert.Equal(t, size, sm.fileSize())
+}
@@ -0,0 +1,331 @@
+package smg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// FeedFormat is used for defining the output format of Feed.
+type FeedFormat int
+
+// predefined FeedFormat values
+const (
+	// RSS is the RSS 2.0 feed format.
+	RSS FeedFormat = iota
+	// Atom is the Atom 1.0 feed format.
+	Atom
+)
+
+const (
+	defaultMaxFeedEntries int    = 100
+	atomXmlns             string = "http://www.w3.org/2005/Atom"
+)
+
+// FeedEntry contains a SitemapLoc and optional Title and Summary
+// which are used for building an item of Feed.
+type FeedEntry struct {
+	*SitemapLoc
+	Title   string
+	Summary string
+}
+
+// Feed builds an RSS 2.0 or Atom feed of the most recently modified URLs
+// from the same SitemapLoc items which are added into a Sitemap.
+// Options are used like Sitemap: Name is the filename without extension,
+// Hostname is prepended to all URLs and is used as the feed link,
+// OutputPath is the dir path to save the feed file and Compress makes
+// the output file gzip compressed.
+// Title and Description are the attributes of the feed itself.
+type Feed struct {
+	Options
+	Title       string
+	Description string
+	format      FeedFormat
+	maxEntries  int
+	entries     []*FeedEntry
+}
+
+// NewFeed builds and returns a new Feed using the format.
+func NewFeed(format FeedFormat, prettyPrint bool) *Feed {
+	f := &Feed{
+		format:     format,
+		maxEntries: defaultMaxFeedEntries,
+		entries:    make([]*FeedEntry, 0),
+	}
+	f.Name = "feed"
+	f.Compress = true
+	f.prettyPrint = prettyPrint
+	return f
+}
+
+// Add adds an URL into the Feed without any title and summary.
+func (f *Feed) Add(u *SitemapLoc) error {
+	return f.AddEntry(&FeedEntry{SitemapLoc: u})
+}
+
+// AddEntry adds a FeedEntry into the Feed. Loc of the entry is resolved
+// against the Hostname. only the most recently modified entries
+// are kept based on the max entries count.
+func (f *Feed) AddEntry(e *FeedEntry) error {
+	if e.SitemapLoc == nil {
+		return fmt.Errorf("feed entry has no SitemapLoc")
+	}
+	loc, err := resolveLoc(f.Hostname, e.Loc)
+	if err != nil {
+		return err
+	}
+	u := *e.SitemapLoc
+	u.Loc = loc
+	f.entries = append(f.entries, &FeedEntry{
+		SitemapLoc: &u,
+		Title:      e.Title,
+		Summary:    e.Summary,
+	})
+
+	// Keeps the memory usage bounded for large streams of URLs
+	if len(f.entries) >= 2*f.maxEntries {
+		f.trimEntries()
+	}
+	return nil
+}
+
+// trimEntries sorts the entries by LastMod descending and
+// removes the entries which exceed the max entries count.
+func (f *Feed) trimEntries() {
+	sort.SliceStable(f.entries, func(i, j int) bool {
+		return lastModOf(f.entries[i].SitemapLoc).After(lastModOf(f.entries[j].SitemapLoc))
+	})
+	if len(f.entries) > f.maxEntries {
+		f.entries = f.entries[:f.maxEntries]
+	}
+}
+
+// SetName sets the Name of Feed output file. It must be without ".xml" extension.
+func (f *Feed) SetName(name string) {
+	f.Name = name
+}
+
+// SetHostname sets the Hostname of Feed which be prepended to all URLs
+// and is used as the link of the feed.
+func (f *Feed) SetHostname(hostname string) {
+	f.Hostname = hostname
+}
+
+// SetOutputPath sets the OutputPath of Feed which will be used to save the file.
+func (f *Feed) SetOutputPath(outputPath string) {
+	f.OutputPath = outputPath
+}
+
+// SetStorage sets the Storage which is used by Save method for writing the file.
+func (f *Feed) SetStorage(storage Storage) {
+	f.storage = storage
+	f.passClock()
+}
+
+// SetCompress sets the Compress option to be either enabled or disabled for Feed.
+func (f *Feed) SetCompress(compress bool) {
+	f.Compress = compress
+}
+
+// SetClock sets the clock which is used instead of time.Now for the updated time of feed without entries.
+func (f *Feed) SetClock(now func() time.Time) {
+	f.now = now
+	f.passClock()
+}
+
+// SetTitle sets the Title of the feed.
+func (f *Feed) SetTitle(title string) {
+	f.Title = title
+}
+
+// SetDescription sets the Description of the feed.
+func (f *Feed) SetDescription(description string) {
+	f.Description = description
+}
+
+// SetMaxEntries sets the maximum # of the most recently modified URLs in the feed
+// which must be at least 1. Default is 100.
+func (f *Feed) SetMaxEntries(maxEntries int) error {
+	if maxEntries < 1 {
+		return fmt.Errorf("max entries %d must be at least 1", maxEntries)
+	}
+	f.maxEntries = maxEntries
+	return nil
+}
+
+// WriteTo writes the encoded feed to given io.Writer.
+// Implements io.WriterTo interface.
+func (f *Feed) WriteTo(writer io.Writer) (int64, error) {
+	f.trimEntries()
+
+	var feed interface{}
+	if f.format == Atom {
+		feed = f.atomFeed()
+	} else {
+		feed = f.rssFeed()
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	if f.prettyPrint {
+		encoder.Indent("", "  ")
+	}
+	err := encoder.Encode(feed)
+	if err != nil {
+		return 0, err
+	}
+	buf.WriteByte('\n')
+	return buf.WriteTo(writer)
+}
+
+// Save makes the OutputPath in case of absence and saves the Feed into OutputPath using it's Name.
+// it returns the filename.
+func (f *Feed) Save() (string, error) {
+	filename := f.Name + fileExt
+	if f.Compress {
+		filename = f.Name + fileGzExt
+	}
+
+	buf := bytes.Buffer{}
+	_, err := f.WriteTo(&buf)
+	if err != nil {
+		return "", err
+	}
+	_, err = f.writeFile(filename, buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+// updated returns the most recent LastMod of the entries
+// or the current time in case of absence.
+func (f *Feed) updated() time.Time {
+	for _, e := range f.entries {
+		if e.LastMod != nil {
+			return e.LastMod.UTC()
+		}
+	}
+	return f.Now().UTC()
+}
+
+func (f *Feed) rssFeed() *rssFeed {
+	feed := &rssFeed{
+		Version: "2.0",
+		Channel: &rssChannel{
+			Title:         f.Title,
+			Link:          f.Hostname,
+			Description:   f.Description,
+			LastBuildDate: f.updated().Format(time.RFC1123Z),
+			Items:         make([]*rssItem, len(f.entries)),
+		},
+	}
+	for i, e := range f.entries {
+		item := &rssItem{
+			Title:       e.Title,
+			Link:        e.Loc,
+			GUID:        &rssGUID{IsPermaLink: "true", Value: e.Loc},
+			Description: e.Summary,
+		}
+		if e.LastMod != nil {
+			item.PubDate = e.LastMod.Format(time.RFC1123Z)
+		}
+		feed.Channel.Items[i] = item
+	}
+	return feed
+}
+
+func (f *Feed) atomFeed() *atomFeed {
+	feed := &atomFeed{
+		Xmlns:   atomXmlns,
+		Title:   f.Title,
+		ID:      f.Hostname,
+		Link:    &atomLink{Href: f.Hostname},
+		Updated: f.updated().Format(time.RFC3339),
+		Entries: make([]*atomEntry, len(f.entries)),
+	}
+	if f.Description != "" {
+		feed.Subtitle = f.Description
+	}
+	for i, e := range f.entries {
+		entry := &atomEntry{
+			Title:   e.Title,
+			ID:      e.Loc,
+			Link:    &atomLink{Href: e.Loc},
+			Updated: lastModOf(e.SitemapLoc).UTC().Format(time.RFC3339),
+			Summary: e.Summary,
+		}
+		// Title and updated are required elements of an Atom entry
+		if entry.Title == "" {
+			entry.Title = e.Loc
+		}
+		if e.LastMod == nil {
+			entry.Updated = feed.Updated
+		}
+		feed.Entries[i] = entry
+	}
+	return feed
+}
+
+// lastModOf returns the LastMod of u or zero time in case of absence.
+func lastModOf(u *SitemapLoc) time.Time {
+	if u.LastMod == nil {
+		return time.Time{}
+	}
+	return *u.LastMod
+}
+
+type rssFeed struct {
+	XMLName xml.Name    `xml:"rss"`
+	Version string      `xml:"version,attr"`
+	Channel *rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate,omitempty"`
+	Items         []*rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title,omitempty"`
+	Link        string   `xml:"link"`
+	GUID        *rssGUID `xml:"guid"`
+	Description string   `xml:"description,omitempty"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomFeed struct {
+	XMLName  xml.Name     `xml:"feed"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Title    string       `xml:"title"`
+	Subtitle string       `xml:"subtitle,omitempty"`
+	ID       string       `xml:"id"`
+	Link     *atomLink    `xml:"link"`
+	Updated  string       `xml:"updated"`
+	Entries  []*atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Title   string    `xml:"title"`
+	ID      string    `xml:"id"`
+	Link    *atomLink `xml:"link"`
+	Updated string    `xml:"updated"`
+	Summary string    `xml:"summary,omitempty"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
@@ -0,0 +1,115 @@
+package smg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type RSSXml struct {
+	XMLName xml.Name `xml:"rss"`
+	Items   []struct {
+		Title   string `xml:"title"`
+		Link    string `xml:"link"`
+		PubDate string `xml:"pubDate"`
+	} `xml:"channel>item"`
+}
+
+type AtomXml struct {
+	XMLName xml.Name `xml:"feed"`
+	Updated string   `xml:"updated"`
+	Entries []struct {
+		Title   string `xml:"title"`
+		ID      string `xml:"id"`
+		Updated string `xml:"updated"`
+		Summary string `xml:"summary"`
+	} `xml:"entry"`
+}
+
+// TestRSSFeed tests that RSS feed contains the most recently modified URLs
+func TestRSSFeed(t *testing.T) {
+	path := t.TempDir()
+	now := time.Date(2022, 2, 12, 16, 29, 46, 0, time.UTC)
+
+	feed := NewFeed(RSS, true)
+	feed.SetHostname(baseURL)
+	feed.SetOutputPath(path)
+	feed.SetTitle("Example")
+	assert.Error(t, feed.SetMaxEntries(-1))
+	assert.Error(t, feed.SetMaxEntries(0))
+	assert.NoError(t, feed.SetMaxEntries(3))
+	for i := 0; i < 10; i++ {
+		lastMod := now.Add(time.Duration(i) * time.Hour)
+		err := feed.AddEntry(&FeedEntry{
+			SitemapLoc: &SitemapLoc{Loc: fmt.Sprintf("/post/%d", i), LastMod: &lastMod},
+			Title:      fmt.Sprintf("Post %d", i),
+		})
+		if err != nil {
+			t.Fatal("Unable to add FeedEntry:", err)
+		}
+	}
+	err := feed.Add(&SitemapLoc{Loc: "/no-lastmod"})
+	if err != nil {
+		t.Fatal("Unable to add SitemapLoc:", err)
+	}
+
+	buf := bytes.Buffer{}
+	_, err = feed.WriteTo(&buf)
+	if err != nil {
+		t.Fatal("Unable to write to buffer:", err)
+	}
+	var rss RSSXml
+	err = xml.Unmarshal(buf.Bytes(), &rss)
+	if err != nil {
+		t.Fatal("Unable to unmarhsall feed byte array into xml: ", err)
+	}
+	assert.Len(t, rss.Items, 3)
+	assert.Equal(t, baseURL+"/post/9", rss.Items[0].Link)
+	assert.Equal(t, "Post 9", rss.Items[0].Title)
+	assert.Equal(t, now.Add(9*time.Hour).Format(time.RFC1123Z), rss.Items[0].PubDate)
+	assert.Equal(t, baseURL+"/post/7", rss.Items[2].Link)
+
+	filename, err := feed.Save()
+	if err != nil {
+		t.Fatal("Unable to Save Feed:", err)
+	}
+	assertOutputFile(t, path, filename)
+	assert.Equal(t, "feed"+fileGzExt, filename)
+}
+
+// TestAtomFeed tests that Atom feed has the required elements
+func TestAtomFeed(t *testing.T) {
+	now := time.Date(2022, 2, 12, 16, 29, 46, 0, time.UTC)
+
+	feed := NewFeed(Atom, false)
+	feed.SetHostname(baseURL)
+	feed.SetTitle("Example")
+	err := feed.AddEntry(&FeedEntry{
+		SitemapLoc: &SitemapLoc{Loc: "/post/1", LastMod: &now},
+		Summary:    "A summary",
+	})
+	if err != nil {
+		t.Fatal("Unable to add FeedEntry:", err)
+	}
+
+	buf := bytes.Buffer{}
+	_, err = feed.WriteTo(&buf)
+	if err != nil {
+		t.Fatal("Unable to write to buffer:", err)
+	}
+	var atom AtomXml
+	err = xml.Unmarshal(buf.Bytes(), &atom)
+	if err != nil {
+		t.Fatal("Unable to unmarhsall feed byte array into xml: ", err)
+	}
+	assert.Equal(t, "2022-02-12T16:29:46Z", atom.Updated)
+	assert.Len(t, atom.Entries, 1)
+	assert.Equal(t, baseURL+"/post/1", atom.Entries[0].ID)
+	assert.Equal(t, baseURL+"/post/1", atom.Entries[0].Title)
+	assert.Equal(t, "2022-02-12T16:29:46Z", atom.Entries[0].Updated)
+	assert.Equal(t, "A summary", atom.Entries[0].Summary)
+}
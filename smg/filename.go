@@ -0,0 +1,118 @@
+package smg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultFilenameHashLen int = 12
+
+// filenamePlaceholder matches the placeholders of filename templates.
+var filenamePlaceholder = regexp.MustCompile(`\{([a-z]*)(?::(\d+))?\}`)
+
+// filenameTemplate is a parsed filename template of Sitemap parts.
+type filenameTemplate string
+
+// filenameData is the data which is used for executing a filenameTemplate for a part.
+type filenameData struct {
+	name      string
+	part      int
+	partCount int
+	date      time.Time
+	content   [][]byte
+}
+
+// parseFilenameTemplate validates the placeholders of template.
+// {name} is the Name of Sitemap, {part} is the 1-based part number which is zero-padded to the
+// digits of the parts count or N digits of {part:N}, {date} is the date of LastMod of Sitemap in
+// UTC and {hash} is the first 12 or N hex digits of {hash:N} of SHA-256 of the uncompressed content.
+func parseFilenameTemplate(template string) (filenameTemplate, error) {
+	if template == "" {
+		return "", nil
+	}
+	if strings.ContainsAny(template, `/\`) {
+		return "", fmt.Errorf("filename template %q must not contain path separators", template)
+	}
+	for _, match := range filenamePlaceholder.FindAllStringSubmatch(template, -1) {
+		switch match[1] {
+		case "name", "date":
+			if match[2] != "" {
+				return "", fmt.Errorf("filename template placeholder %s does not accept a width", match[0])
+			}
+		case "part", "hash":
+		default:
+			return "", fmt.Errorf("unknown filename template placeholder %s", match[0])
+		}
+	}
+	rest := filenamePlaceholder.ReplaceAllString(template, "")
+	if strings.ContainsAny(rest, "{}") {
+		return "", fmt.Errorf("invalid filename template %q", template)
+	}
+	return filenameTemplate(template), nil
+}
+
+// execute returns the filename of a part without extension.
+func (t filenameTemplate) execute(data *filenameData) string {
+	return filenamePlaceholder.ReplaceAllStringFunc(string(t), func(placeholder string) string {
+		match := filenamePlaceholder.FindStringSubmatch(placeholder)
+		width, _ := strconv.Atoi(match[2])
+		switch match[1] {
+		case "name":
+			return data.name
+		case "part":
+			if width == 0 {
+				width = len(strconv.Itoa(data.partCount))
+			}
+			return fmt.Sprintf("%0*d", width, data.part)
+		case "date":
+			return data.date.UTC().Format("2006-01-02")
+		case "hash":
+			return contentHash(data.content, width)
+		}
+		return placeholder
+	})
+}
+
+// hasHash reports whether the template contains the {hash} placeholder.
+func (t filenameTemplate) hasHash() bool {
+	for _, match := range filenamePlaceholder.FindAllStringSubmatch(string(t), -1) {
+		if match[1] == "hash" {
+			return true
+		}
+	}
+	return false
+}
+
+// contentHash returns the first n hex digits of SHA-256 of the contents
+// or defaultFilenameHashLen digits in case of n <= 0.
+func contentHash(contents [][]byte, n int) string {
+	if n <= 0 {
+		n = defaultFilenameHashLen
+	}
+	h := sha256.New()
+	for _, content := range contents {
+		h.Write(content)
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if n < len(sum) {
+		sum = sum[:n]
+	}
+	return sum
+}
+
+// duplicateFilename returns the first filename which appears more than once in filenames.
+func duplicateFilename(filenames []string) (string, bool) {
+	seen := make(map[string]bool, len(filenames))
+	for _, filename := range filenames {
+		if seen[filename] {
+			return filename, true
+		}
+		seen[filename] = true
+	}
+	return "", false
+}
@@ -0,0 +1,171 @@
+package smg
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSitemapFilenameTemplate tests naming the parts of a Sitemap using a filename template
+func TestSitemapFilenameTemplate(t *testing.T) {
+	storage := NewMemoryStorage()
+	sm := NewSitemap(false)
+	sm.SetHostname(baseURL)
+	sm.SetStorage(storage)
+	sm.SetCompress(false)
+	sm.SetMaxURLsCount(2)
+	for i := 0; i < 5; i++ {
+		err := sm.Add(&SitemapLoc{Loc: "/page/" + strings.Repeat("a", i)})
+		if err != nil {
+			t.Fatal("Unable to add SitemapLoc:", err)
+		}
+	}
+	lastMod := time.Date(2022, 3, 4, 23, 0, 0, 0, time.FixedZone("", -2*60*60))
+	sm.SetLastMod(&lastMod)
+	err := sm.SetFilenameTemplate("{name}-{date}-{part:3}")
+	if err != nil {
+		t.Fatal("Unable to set filename template:", err)
+	}
+
+	filenames, err := sm.Save()
+	if err != nil {
+		t.Fatal("Unable to Save Sitemap:", err)
+	}
+	assert.Equal(t, []string{"sitemap-2022-03-05-001.xml", "sitemap-2022-03-05-002.xml", "sitemap-2022-03-05-003.xml"}, filenames)
+	assert.ElementsMatch(t, filenames, storage.Filenames())
+}
+
+// TestSitemapFilenameTemplateHash tests the content hash placeholder and invalid templates
+func TestSitemapFilenameTemplateHash(t *testing.T) {
+	build := func(loc string) string {
+		sm := NewSitemap(false)
+		sm.SetStorage(NewMemoryStorage())
+		sm.SetHostname(baseURL)
+		assert.NoError(t, sm.SetFilenameTemplate("{name}.{hash:8}"))
+		assert.NoError(t, sm.Add(&SitemapLoc{Loc: loc}))
+		filenames, err := sm.Save()
+		if err != nil {
+			t.Fatal("Unable to Save Sitemap:", err)
+		}
+		return filenames[0]
+	}
+	filename := build("/a")
+	assert.Regexp(t, `^sitemap\.[0-9a-f]{8}\.xml\.gz$`, filename)
+	assert.Equal(t, filename, build("/a"))
+	assert.NotEqual(t, filename, build("/b"))
+
+	sm := NewSitemap(false)
+	for _, template := range []string{"{unknown}", "{name:2}", "{name", "dir/{name}"} {
+		assert.Error(t, sm.SetFilenameTemplate(template), template)
+	}
+
+	// parts can not share a filename
+	sm.SetStorage(NewMemoryStorage())
+	sm.SetMaxURLsCount(1)
+	assert.NoError(t, sm.SetFilenameTemplate("{name}"))
+	assert.NoError(t, sm.Add(&SitemapLoc{Loc: "/a"}))
+	assert.NoError(t, sm.Add(&SitemapLoc{Loc: "/b"}))
+	_, err := sm.Save()
+	assert.Error(t, err)
+}
+
+// TestSitemapIndexFilenameCollision tests that the default names of parts and Sitemaps do not collide
+// and the collision of custom names is detected before writing
+func TestSitemapIndexFilenameCollision(t *testing.T) {
+	storage := NewMemoryStorage()
+	smi := NewSitemapIndex(false)
+	smi.SetHostname(baseURL)
+	smi.SetStorage(storage)
+	smi.SetCompress(false)
+	for i := 0; i < 11; i++ {
+		sm := smi.NewSitemap()
+		assert.NoError(t, sm.Add(&SitemapLoc{Loc: "/a"}))
+	}
+	// the second part of sitemap1 is sitemap1-2 which is distinct from sitemap11
+	smi.Sitemaps[0].SetMaxURLsCount(1)
+	assert.NoError(t, smi.Sitemaps[0].Add(&SitemapLoc{Loc: "/b"}))
+	_, err := smi.Save()
+	if err != nil {
+		t.Fatal("Unable to Save SitemapIndex:", err)
+	}
+	assert.Contains(t, storage.Filenames(), "sitemap1-2.xml")
+	assert.Contains(t, storage.Filenames(), "sitemap11.xml")
+
+	// the second part of news collides with the custom name of another Sitemap
+	storage = NewMemoryStorage()
+	smi = NewSitemapIndex(false)
+	smi.SetHostname(baseURL)
+	smi.SetStorage(storage)
+	smi.SetCompress(false)
+	news := smi.NewSitemap()
+	news.SetName("news")
+	news.SetMaxURLsCount(1)
+	assert.NoError(t, news.Add(&SitemapLoc{Loc: "/a"}))
+	assert.NoError(t, news.Add(&SitemapLoc{Loc: "/b"}))
+	other := smi.NewSitemap()
+	other.SetName("news-2")
+	assert.NoError(t, other.Add(&SitemapLoc{Loc: "/c"}))
+
+	_, err = smi.Save()
+	assert.Error(t, err)
+	assert.Empty(t, storage.Filenames())
+
+	assert.NoError(t, smi.SetFilenameTemplate("{name}-{part}"))
+	_, err = smi.Save()
+	if err != nil {
+		t.Fatal("Unable to Save SitemapIndex:", err)
+	}
+	assert.Contains(t, storage.Filenames(), "news-1.xml")
+	assert.Contains(t, storage.Filenames(), "news-2.xml")
+	assert.Contains(t, storage.Filenames(), "news-2-1.xml")
+	content, err := storage.ReadFile("sitemap.xml")
+	if err != nil {
+		t.Fatal("Unable to read sitemap index:", err)
+	}
+	assert.Contains(t, string(content), "<loc>"+baseURL+"/news-2-1.xml</loc>")
+}
+
+// TestSitemapIndexHashFilenames tests that unchanged Sitemaps keep their hashed filenames in the index
+func TestSitemapIndexHashFilenames(t *testing.T) {
+	build := func(locs ...string) (*MemoryStorage, string) {
+		storage := NewMemoryStorage()
+		smi := NewSitemapIndex(false)
+		smi.SetHostname(baseURL)
+		smi.SetStorage(storage)
+		smi.SetHashFilenames(true)
+		smi.SetCompress(false)
+		for _, loc := range locs {
+			sm := smi.NewSitemap()
+			assert.NoError(t, sm.Add(&SitemapLoc{Loc: loc}))
+		}
+		filename, err := smi.Save()
+		if err != nil {
+			t.Fatal("Unable to Save SitemapIndex:", err)
+		}
+		assert.Equal(t, "sitemap.xml", filename)
+		content, err := storage.ReadFile(filename)
+		if err != nil {
+			t.Fatal("Unable to read sitemap index:", err)
+		}
+		return storage, string(content)
+	}
+
+	first, index := build("/a", "/b")
+	for _, filename := range first.Filenames() {
+		if filename != "sitemap.xml" {
+			assert.Regexp(t, `^sitemap[12]-[0-9a-f]{12}\.xml$`, filename)
+			assert.Contains(t, index, "<loc>"+baseURL+"/"+filename+"</loc>")
+		}
+	}
+	second, _ := build("/a", "/c")
+	assert.Len(t, second.Filenames(), 3)
+	var unchanged int
+	for _, filename := range second.Filenames() {
+		if _, err := first.ReadFile(filename); err == nil {
+			unchanged++
+		}
+	}
+	assert.Equal(t, 2, unchanged, "the index and the first sitemap keep their names")
+}
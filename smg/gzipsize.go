@@ -0,0 +1,79 @@
+package smg
+
+import (
+	"compress/gzip"
+)
+
+const (
+	gzipTrailerLen     int = 8     // CRC-32 and size of gzip trailer
+	deflateBlockLen    int = 5     // header of a stored deflate block
+	deflateMaxBlockLen int = 65535 // maximum length of a stored deflate block
+)
+
+// countingWriter counts the written bytes and discards them.
+type countingWriter struct {
+	n int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += len(p)
+	return len(p), nil
+}
+
+// gzipSize tracks the compressed size of a Sitemap part incrementally while its URLs are added.
+// The compressor keeps a part of the written bytes in its buffers, so the pending bytes are
+// counted as stored blocks which is an upper bound until the compressor is flushed.
+type gzipSize struct {
+	writer  *gzip.Writer
+	counter *countingWriter
+	pending int
+}
+
+// newGzipSize builds a gzipSize which has compressed the content using the gzip level.
+func newGzipSize(level int, content ...[]byte) *gzipSize {
+	counter := &countingWriter{}
+	writer, err := gzip.NewWriterLevel(counter, level)
+	if err != nil {
+		writer = gzip.NewWriter(counter)
+	}
+	g := &gzipSize{
+		writer:  writer,
+		counter: counter,
+	}
+	for _, bytes := range content {
+		g.write(bytes)
+	}
+	return g
+}
+
+// write compresses p.
+func (g *gzipSize) write(p []byte) {
+	_, _ = g.writer.Write(p)
+	g.pending += len(p)
+}
+
+// fits reports whether the compressed size stays within limit after writing n more bytes
+// and the footer of footerLen bytes. It flushes the compressor only when the estimated
+// upper bound exceeds the limit.
+func (g *gzipSize) fits(n, footerLen, limit int) bool {
+	if g.counter.n+storedLen(g.pending+n+footerLen)+gzipTrailerLen <= limit {
+		return true
+	}
+	if g.pending > 0 {
+		_ = g.writer.Flush()
+		g.pending = 0
+	}
+	return g.counter.n+storedLen(n+footerLen)+gzipTrailerLen <= limit
+}
+
+// compressedLen returns the size of content which is compressed using the gzip level.
+func compressedLen(level int, content ...[]byte) int {
+	g := newGzipSize(level, content...)
+	_ = g.writer.Close()
+	return g.counter.n
+}
+
+// storedLen returns the size of n bytes which are written as stored deflate blocks.
+func storedLen(n int) int {
+	return n + (n/deflateMaxBlockLen+1)*deflateBlockLen
+}
@@ -0,0 +1,289 @@
+package smg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	gzipExt         string        = ".gz"
+	defaultMaxAge   time.Duration = time.Hour
+	identityETagTag string        = "-identity"
+)
+
+var contentTypes = map[string]string{
+	".xml":  "application/xml; charset=utf-8",
+	".txt":  "text/plain; charset=utf-8",
+	".xsl":  "text/xsl; charset=utf-8",
+	".html": "text/html; charset=utf-8",
+	".gz":   "application/gzip",
+}
+
+// Handler is an http.Handler which serves the saved files of Sitemap and SitemapIndex
+// from an fs.FS like MemoryStorage or os.DirFS of the OutputPath.
+// Requests of a file without .gz extension are served from the gzip compressed file in case
+// of absence of the plain file, either with gzip Content-Encoding or decompressed for clients
+// which do not accept gzip. The files are streamed using http.ServeContent which answers the
+// conditional and range requests using the ETag and Last-Modified headers. ETags are cached by
+// name and modification time, so the files are read for computing them only after being modified.
+// Use http.StripPrefix in case of serving the files under the ServerURI.
+type Handler struct {
+	fsys   fs.FS
+	maxAge time.Duration
+	etags  map[string]cachedETag
+	mutex  sync.Mutex
+}
+
+// cachedETag is the ETag of a file of Handler which is valid until the file is modified.
+type cachedETag struct {
+	modTime time.Time
+	etag    string
+}
+
+// servedFile contains the stored content of a file which is served by serveFile.
+type servedFile struct {
+	name       string
+	content    io.ReadSeeker
+	compressed bool
+	modTime    time.Time
+	etag       string
+}
+
+// NewHandler builds and returns a new Handler which serves the files of fsys.
+func NewHandler(fsys fs.FS) *Handler {
+	return &Handler{
+		fsys:   fsys,
+		maxAge: defaultMaxAge,
+		etags:  make(map[string]cachedETag),
+	}
+}
+
+// SetMaxAge sets the max-age of Cache-Control header. Default is one hour.
+func (h *Handler) SetMaxAge(maxAge time.Duration) {
+	h.maxAge = maxAge
+}
+
+// ServeHTTP serves the requested file. Implements http.Handler interface.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	candidates := []string{name}
+	if !strings.HasSuffix(name, gzipExt) {
+		if acceptsGzip(r) {
+			// prefers the precompressed file for clients which accept gzip
+			candidates = []string{name + gzipExt, name}
+		} else {
+			candidates = append(candidates, name+gzipExt)
+		}
+	}
+	for _, candidate := range candidates {
+		file, f, err := h.open(candidate)
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+		file.name = name
+		serveFile(w, r, file, h.cacheControl())
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// open opens the named file of fsys and returns it as a servedFile and the opened
+// file which must be closed after serving. The files which do not implement
+// io.Seeker are read into memory.
+func (h *Handler) open(name string) (*servedFile, fs.File, error) {
+	f, err := h.fsys.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := h.stat(name, f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return file, f, nil
+}
+
+func (h *Handler) stat(name string, f fs.File) (*servedFile, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fs.ErrNotExist
+	}
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		content = bytes.NewReader(data)
+	}
+	etag, err := h.etag(name, info.ModTime(), content)
+	if err != nil {
+		return nil, err
+	}
+	return &servedFile{
+		content:    content,
+		compressed: strings.HasSuffix(name, gzipExt),
+		modTime:    info.ModTime(),
+		etag:       etag,
+	}, nil
+}
+
+// etag returns the ETag of content which is cached by name and is replaced when
+// the modTime of file changes. content is read only in case of a cache miss and
+// is rewound afterwards.
+func (h *Handler) etag(name string, modTime time.Time, content io.ReadSeeker) (string, error) {
+	h.mutex.Lock()
+	cached, ok := h.etags[name]
+	h.mutex.Unlock()
+	if ok && cached.modTime.Equal(modTime) {
+		return cached.etag, nil
+	}
+
+	hash := sha256.New()
+	_, err := io.Copy(hash, content)
+	if err != nil {
+		return "", err
+	}
+	_, err = content.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", err
+	}
+	cached = cachedETag{modTime: modTime, etag: checksumETag(hash.Sum(nil))}
+	h.mutex.Lock()
+	h.etags[name] = cached
+	h.mutex.Unlock()
+	return cached.etag, nil
+}
+
+func (h *Handler) cacheControl() string {
+	return fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds()))
+}
+
+// serveFile writes the file into w based on the request headers using http.ServeContent.
+// The compressed content of a file which is requested without .gz extension
+// is served with gzip Content-Encoding or is decompressed in case the client
+// does not accept gzip.
+func serveFile(w http.ResponseWriter, r *http.Request, f *servedFile, cacheControl string) {
+	content := f.content
+	etag := f.etag
+
+	header := w.Header()
+	requestedGz := strings.HasSuffix(f.name, gzipExt)
+	if !requestedGz {
+		// the content of files without .gz extension depends on Accept-Encoding
+		header.Set("Vary", "Accept-Encoding")
+	}
+	if f.compressed && !requestedGz {
+		if acceptsGzip(r) {
+			header.Set("Content-Encoding", "gzip")
+		} else {
+			decompressed, err := gunzipReader(content)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			content = bytes.NewReader(decompressed)
+			etag = strings.TrimSuffix(etag, `"`) + identityETagTag + `"`
+		}
+	}
+
+	header.Set("Content-Type", contentType(f.name))
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", cacheControl)
+	http.ServeContent(w, r, f.name, f.modTime.UTC(), content)
+}
+
+// acceptsGzip checks whether the client accepts gzip Content-Encoding.
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(encoding, ";")
+		coding := strings.TrimSpace(parts[0])
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if value, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = value
+				}
+			}
+		}
+		return q > 0
+	}
+	return false
+}
+
+// contentType returns the Content-Type of name by its extension.
+func contentType(name string) string {
+	ext := path.Ext(name)
+	if t, ok := contentTypes[ext]; ok {
+		return t
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// contentETag returns a strong ETag of content using its SHA-256 checksum.
+func contentETag(content []byte) string {
+	sum := sha256.Sum256(content)
+	return checksumETag(sum[:])
+}
+
+// checksumETag returns a strong ETag of a SHA-256 checksum.
+func checksumETag(sum []byte) string {
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func gzipBytes(content []byte) ([]byte, error) {
+	buf := bytes.Buffer{}
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(content)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Gunzip returns the uncompressed content of a gzip compressed file, e.g. a saved ".xml.gz" Sitemap.
+func Gunzip(content []byte) ([]byte, error) {
+	return gunzipReader(bytes.NewReader(content))
+}
+
+func gunzipReader(content io.Reader) ([]byte, error) {
+	r, err := gzip.NewReader(content)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
@@ -0,0 +1,153 @@
+package smg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serveRequest(h http.Handler, method, target string, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	for key, value := range headers {
+		r.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// TestHandlerMemoryStorage tests serving a SitemapIndex which is saved into a MemoryStorage
+func TestHandlerMemoryStorage(t *testing.T) {
+	storage := NewMemoryStorage()
+	smi := NewSitemapIndex(false)
+	smi.SetHostname(baseURL)
+	smi.SetStorage(storage)
+	err := smi.NewSitemap().Add(&SitemapLoc{Loc: "/test"})
+	if err != nil {
+		t.Fatal("Unable to add SitemapLoc:", err)
+	}
+	_, err = smi.Save()
+	if err != nil {
+		t.Fatal("Unable to Save SitemapIndex:", err)
+	}
+	assert.ElementsMatch(t, []string{"sitemap.xml.gz", "sitemap1.xml.gz"}, storage.Filenames())
+
+	h := NewHandler(storage)
+
+	// gzip Content-Encoding for clients which accept gzip
+	w := serveRequest(h, http.MethodGet, "/sitemap.xml", map[string]string{"Accept-Encoding": "gzip, deflate"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	gzETag := w.Header().Get("ETag")
+	assert.NotEmpty(t, gzETag)
+	assert.NotEmpty(t, w.Header().Get("Last-Modified"))
+
+	// decompressed for clients which do not accept gzip
+	w = serveRequest(h, http.MethodGet, "/sitemap.xml", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.NotEqual(t, gzETag, w.Header().Get("ETag"))
+	var sitemapIndex SitemapIndexXml
+	err = xml.Unmarshal(w.Body.Bytes(), &sitemapIndex)
+	if err != nil {
+		t.Fatal("Unable to unmarhsall sitemap byte array into xml: ", err)
+	}
+	assert.Equal(t, baseURL+"/sitemap1.xml.gz", sitemapIndex.Sitemaps[0].Loc)
+
+	// the compressed file as it is
+	w = serveRequest(h, http.MethodGet, "/sitemap1.xml.gz", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/gzip", w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+
+	// conditional requests
+	w = serveRequest(h, http.MethodGet, "/sitemap.xml", map[string]string{"Accept-Encoding": "gzip", "If-None-Match": gzETag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.Bytes())
+	lastModified := serveRequest(h, http.MethodHead, "/sitemap.xml", nil).Header().Get("Last-Modified")
+	w = serveRequest(h, http.MethodGet, "/sitemap.xml", map[string]string{"If-Modified-Since": lastModified})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	assert.Equal(t, http.StatusNotFound, serveRequest(h, http.MethodGet, "/not-found.xml", nil).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serveRequest(h, http.MethodPost, "/sitemap.xml", nil).Code)
+}
+
+// TestHandlerDirFS tests serving plain files from the local OutputPath
+func TestHandlerDirFS(t *testing.T) {
+	path := t.TempDir()
+	sm := NewSitemap(false)
+	sm.SetHostname(baseURL)
+	sm.SetOutputPath(path)
+	sm.SetCompress(false)
+	sm.SetFormat(FormatText)
+	err := sm.Add(&SitemapLoc{Loc: "/test"})
+	if err != nil {
+		t.Fatal("Unable to add SitemapLoc:", err)
+	}
+	_, err = sm.Save()
+	if err != nil {
+		t.Fatal("Unable to Save Sitemap:", err)
+	}
+
+	h := NewHandler(os.DirFS(path))
+	w := serveRequest(h, http.MethodGet, "/sitemap.txt", map[string]string{"Accept-Encoding": "gzip"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=3600", w.Header().Get("Cache-Control"))
+	assert.Equal(t, baseURL+"/test\n", w.Body.String())
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+}
+
+// TestHandlerETagCache tests that the cached ETag of a file is replaced when the file is modified
+func TestHandlerETagCache(t *testing.T) {
+	storage := NewMemoryStorage()
+	now := time.Date(2022, 2, 12, 16, 29, 46, 0, time.UTC)
+	h := NewHandler(storage)
+
+	var etags []string
+	for i := 0; i < 3; i++ {
+		storage.SetClock(FixedClock(now.Add(time.Duration(i) * time.Second)))
+		assert.NoError(t, storage.WriteFile("", "sitemap.txt", []byte(fmt.Sprintf("%s/%d\n", baseURL, i))))
+		w := serveRequest(h, http.MethodGet, "/sitemap.txt", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		etags = append(etags, w.Header().Get("ETag"))
+	}
+	assert.NotEqual(t, etags[0], etags[1])
+	assert.NotEqual(t, etags[1], etags[2])
+	assert.Len(t, h.etags, 1)
+}
+
+// TestHandlerRange tests serving ranges of a file and the If-Modified-Since conditional request
+func TestHandlerRange(t *testing.T) {
+	storage := NewMemoryStorage()
+	now := time.Date(2022, 2, 12, 16, 29, 46, 0, time.UTC)
+	storage.SetClock(FixedClock(now))
+	content := baseURL + "/test\n"
+	assert.NoError(t, storage.WriteFile("", "sitemap.txt", []byte(content)))
+	h := NewHandler(storage)
+
+	w := serveRequest(h, http.MethodGet, "/sitemap.txt", map[string]string{"Range": "bytes=0-4"})
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, fmt.Sprintf("bytes 0-4/%d", len(content)), w.Header().Get("Content-Range"))
+	assert.Equal(t, content[:5], w.Body.String())
+
+	// the whole file in case of a modified If-Range
+	w = serveRequest(h, http.MethodGet, "/sitemap.txt", map[string]string{"Range": "bytes=0-4", "If-Range": `"modified"`})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, content, w.Body.String())
+
+	w = serveRequest(h, http.MethodGet, "/sitemap.txt", map[string]string{"If-Modified-Since": now.Format(http.TimeFormat)})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	w = serveRequest(h, http.MethodGet, "/sitemap.txt", map[string]string{"If-Modified-Since": now.Add(-time.Second).Format(http.TimeFormat)})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, content, w.Body.String())
+	assert.Equal(t, now.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+}
@@ -0,0 +1,321 @@
+package smg
+
+import (
+	"bytes"
+	"compress/gzip"
+	_ "embed" // for embedding the default HTML template
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// HTMLGrouping is used for defining how the links of HTMLSitemap are grouped.
+type HTMLGrouping int
+
+// predefined HTMLGrouping values
+const (
+	// GroupNone keeps the links in the order of adding without any grouping.
+	GroupNone HTMLGrouping = iota
+	// GroupAlphabetical sorts the links by their title and groups them by the first letter.
+	GroupAlphabetical
+	// GroupSection groups the links by their Section in the order of appearance.
+	GroupSection
+)
+
+const (
+	htmlFileExt             string = ".html"
+	defaultHTMLLinksPerPage int    = 1000
+)
+
+var (
+	//go:embed html/sitemap.html
+	defaultHTMLTemplateText string
+	defaultHTMLTemplate     = template.Must(template.New("sitemap").Parse(defaultHTMLTemplateText))
+)
+
+// HTMLLink contains a SitemapLoc and optional Title and Section which
+// are used for rendering a link in HTMLSitemap.
+type HTMLLink struct {
+	*SitemapLoc
+	Title   string
+	Section string
+}
+
+// HTMLGroup contains the links of a group in an HTMLPage.
+type HTMLGroup struct {
+	Name  string
+	Links []*HTMLLink
+}
+
+// HTMLPageLink contains data related to a page link in pagination of HTMLPage.
+type HTMLPageLink struct {
+	Number   int
+	Filename string
+	Current  bool
+}
+
+// HTMLPage is the data which is passed to the template of HTMLSitemap for each page.
+// Prev and Next are the filenames of previous and next pages which are
+// empty in case of first and last pages.
+type HTMLPage struct {
+	Title     string
+	Number    int
+	PageCount int
+	Filename  string
+	Groups    []*HTMLGroup
+	Pages     []*HTMLPageLink
+	Prev      string
+	Next      string
+}
+
+// HTMLSitemap renders the human-facing paginated HTML sitemap pages using html/template
+// from the SitemapLoc items, Sitemap or SitemapIndex.
+// Options are used like Sitemap: Name is the filename of the first page without extension,
+// Hostname is prepended to all URLs and OutputPath is the dir path to save the pages.
+type HTMLSitemap struct {
+	Options
+	Title        string
+	links        []*HTMLLink
+	linksPerPage int
+	grouping     HTMLGrouping
+	template     *template.Template
+}
+
+// NewHTMLSitemap builds and returns a new HTMLSitemap with the default template.
+func NewHTMLSitemap() *HTMLSitemap {
+	s := &HTMLSitemap{
+		Title:        "Sitemap",
+		links:        make([]*HTMLLink, 0),
+		linksPerPage: defaultHTMLLinksPerPage,
+		template:     defaultHTMLTemplate,
+	}
+	s.Name = "sitemap"
+	return s
+}
+
+// Add adds an URL into the HTMLSitemap without any title and section.
+func (s *HTMLSitemap) Add(u *SitemapLoc) error {
+	return s.AddLink(&HTMLLink{SitemapLoc: u})
+}
+
+// AddLink adds an HTMLLink into the HTMLSitemap. Loc of the link is resolved against the Hostname.
+func (s *HTMLSitemap) AddLink(l *HTMLLink) error {
+	if l.SitemapLoc == nil {
+		return fmt.Errorf("html link has no SitemapLoc")
+	}
+	loc, err := resolveLoc(s.Hostname, l.Loc)
+	if err != nil {
+		return err
+	}
+	u := *l.SitemapLoc
+	u.Loc = loc
+	s.links = append(s.links, &HTMLLink{
+		SitemapLoc: &u,
+		Title:      l.Title,
+		Section:    l.Section,
+	})
+	return nil
+}
+
+// AddSitemap adds all URLs of the Sitemap and it's NextSitemaps into the HTMLSitemap.
+// the Name of Sitemap is used as Section of the links.
+func (s *HTMLSitemap) AddSitemap(sm *Sitemap) error {
+	return sm.decodeLocs(func(u *SitemapLoc) error {
+		return s.AddLink(&HTMLLink{SitemapLoc: u, Section: sm.Name})
+	})
+}
+
+// AddSitemapIndex adds all URLs of the Sitemaps of SitemapIndex into the HTMLSitemap.
+func (s *HTMLSitemap) AddSitemapIndex(smi *SitemapIndex) error {
+	for _, sm := range smi.Sitemaps {
+		err := s.AddSitemap(sm)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetName sets the Name of the first page file. It must be without ".html" extension.
+// the other pages are named using the "-" separated page number suffix like the parts
+// of Sitemap files, e.g. sitemap.html, sitemap-2.html, sitemap-3.html.
+func (s *HTMLSitemap) SetName(name string) {
+	s.Name = name
+}
+
+// SetHostname sets the Hostname of HTMLSitemap which be prepended to all URLs.
+func (s *HTMLSitemap) SetHostname(hostname string) {
+	s.Hostname = hostname
+}
+
+// SetOutputPath sets the OutputPath of HTMLSitemap which will be used to save the pages.
+func (s *HTMLSitemap) SetOutputPath(outputPath string) {
+	s.OutputPath = outputPath
+}
+
+// SetStorage sets the Storage which is used by Save method for writing the pages.
+func (s *HTMLSitemap) SetStorage(storage Storage) {
+	s.storage = storage
+}
+
+// SetTitle sets the Title of the pages.
+func (s *HTMLSitemap) SetTitle(title string) {
+	s.Title = title
+}
+
+// SetLinksPerPage sets the maximum # of links in each page which must be at least 1. Default is 1000.
+func (s *HTMLSitemap) SetLinksPerPage(linksPerPage int) error {
+	if linksPerPage < 1 {
+		return fmt.Errorf("links per page %d must be at least 1", linksPerPage)
+	}
+	s.linksPerPage = linksPerPage
+	return nil
+}
+
+// SetGrouping sets the grouping of links in pages. Default is GroupNone.
+func (s *HTMLSitemap) SetGrouping(grouping HTMLGrouping) {
+	s.grouping = grouping
+}
+
+// SetTemplate sets a custom template which is executed with an *HTMLPage for each page.
+func (s *HTMLSitemap) SetTemplate(t *template.Template) {
+	s.template = t
+}
+
+// Pages builds the data of all pages of HTMLSitemap.
+func (s *HTMLSitemap) Pages() []*HTMLPage {
+	links := s.sortedLinks()
+	perPage := s.linksPerPage
+	pageCount := (len(links) + perPage - 1) / perPage
+	if pageCount == 0 {
+		pageCount = 1
+	}
+
+	pages := make([]*HTMLPage, pageCount)
+	for i := range pages {
+		start := i * perPage
+		end := start + perPage
+		if end > len(links) {
+			end = len(links)
+		}
+		page := &HTMLPage{
+			Title:     s.Title,
+			Number:    i + 1,
+			PageCount: pageCount,
+			Filename:  s.pageFilename(i),
+			Groups:    s.group(links[start:end]),
+			Pages:     make([]*HTMLPageLink, pageCount),
+		}
+		for j := range page.Pages {
+			page.Pages[j] = &HTMLPageLink{
+				Number:   j + 1,
+				Filename: s.pageFilename(j),
+				Current:  i == j,
+			}
+		}
+		if i > 0 {
+			page.Prev = s.pageFilename(i - 1)
+		}
+		if i < pageCount-1 {
+			page.Next = s.pageFilename(i + 1)
+		}
+		pages[i] = page
+	}
+	return pages
+}
+
+// Save makes the OutputPath in case of absence and saves all pages of the HTMLSitemap
+// into OutputPath. it returns the filenames in order of pages.
+func (s *HTMLSitemap) Save() ([]string, error) {
+	pages := s.Pages()
+	filenames := make([]string, len(pages))
+	for i, page := range pages {
+		buf := bytes.Buffer{}
+		err := s.template.Execute(&buf, page)
+		if err != nil {
+			return nil, err
+		}
+		_, err = writeToFile(s.fileStorage(), page.Filename, s.OutputPath, gzip.NoCompression, buf.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		filenames[i] = page.Filename
+	}
+	return filenames, nil
+}
+
+// pageFilename returns the filename of the page with i index whose Number is i+1.
+func (s *HTMLSitemap) pageFilename(i int) string {
+	if i > 0 {
+		return fmt.Sprintf("%s-%d%s", s.Name, i+1, htmlFileExt)
+	}
+	return s.Name + htmlFileExt
+}
+
+// sortedLinks returns the links sorted based on the grouping.
+func (s *HTMLSitemap) sortedLinks() []*HTMLLink {
+	links := make([]*HTMLLink, len(s.links))
+	copy(links, s.links)
+
+	switch s.grouping {
+	case GroupAlphabetical:
+		sort.SliceStable(links, func(i, j int) bool {
+			return strings.ToLower(links[i].text()) < strings.ToLower(links[j].text())
+		})
+	case GroupSection:
+		// keeps the order of appearance of sections and links inside each section
+		order := make(map[string]int)
+		for _, link := range links {
+			if _, ok := order[link.Section]; !ok {
+				order[link.Section] = len(order)
+			}
+		}
+		sort.SliceStable(links, func(i, j int) bool {
+			return order[links[i].Section] < order[links[j].Section]
+		})
+	}
+	return links
+}
+
+// group splits the sorted links of a page into groups.
+func (s *HTMLSitemap) group(links []*HTMLLink) []*HTMLGroup {
+	groups := make([]*HTMLGroup, 0)
+	var current *HTMLGroup
+	for _, link := range links {
+		name := s.groupName(link)
+		if current == nil || current.Name != name {
+			current = &HTMLGroup{Name: name}
+			groups = append(groups, current)
+		}
+		current.Links = append(current.Links, link)
+	}
+	return groups
+}
+
+// groupName returns the name of the group which the link belongs to.
+func (s *HTMLSitemap) groupName(link *HTMLLink) string {
+	switch s.grouping {
+	case GroupAlphabetical:
+		for _, r := range link.text() {
+			if unicode.IsLetter(r) {
+				return string(unicode.ToUpper(r))
+			}
+			break
+		}
+		return "#"
+	case GroupSection:
+		return link.Section
+	default:
+		return ""
+	}
+}
+
+// text returns the visible text of the link which is the Title or the Loc in case of absence.
+func (l *HTMLLink) text() string {
+	if l.Title != "" {
+		return l.Title
+	}
+	return l.Loc
+}
@@ -0,0 +1,100 @@
+package smg
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHTMLSitemapPages tests the alphabetical grouping and pagination of HTMLSitemap
+func TestHTMLSitemapPages(t *testing.T) {
+	path := t.TempDir()
+
+	hs := NewHTMLSitemap()
+	hs.SetHostname(baseURL)
+	hs.SetOutputPath(path)
+	hs.SetGrouping(GroupAlphabetical)
+	assert.Error(t, hs.SetLinksPerPage(0))
+	assert.Error(t, hs.SetLinksPerPage(-1))
+	assert.NoError(t, hs.SetLinksPerPage(2))
+	for _, title := range []string{"banana", "Apple", "cherry", "avocado", "<b>"} {
+		err := hs.AddLink(&HTMLLink{SitemapLoc: &SitemapLoc{Loc: "/" + title}, Title: title})
+		if err != nil {
+			t.Fatal("Unable to add HTMLLink:", err)
+		}
+	}
+
+	pages := hs.Pages()
+	assert.Len(t, pages, 3)
+	assert.Equal(t, "#", pages[0].Groups[0].Name)
+	assert.Equal(t, "A", pages[0].Groups[1].Name)
+	assert.Equal(t, "A", pages[1].Groups[0].Name)
+	assert.Equal(t, "avocado", pages[1].Groups[0].Links[0].Title)
+	assert.Equal(t, "B", pages[1].Groups[1].Name)
+	// the filenames follow the page numbers
+	assert.Equal(t, 2, pages[1].Number)
+	assert.Equal(t, "sitemap-2.html", pages[1].Filename)
+	assert.Equal(t, "sitemap.html", pages[1].Prev)
+	assert.Equal(t, "sitemap-3.html", pages[1].Next)
+	assert.Equal(t, "sitemap-3.html", pages[2].Pages[2].Filename)
+	assert.Equal(t, 3, pages[2].Pages[2].Number)
+
+	filenames, err := hs.Save()
+	if err != nil {
+		t.Fatal("Unable to Save HTMLSitemap:", err)
+	}
+	assert.Equal(t, []string{"sitemap.html", "sitemap-2.html", "sitemap-3.html"}, filenames)
+
+	content, err := os.ReadFile(filepath.Join(path, "sitemap.html"))
+	if err != nil {
+		t.Fatal("Unable to read file:", err)
+	}
+	assert.Contains(t, string(content), `<a href="https://www.example.com/Apple">Apple</a>`)
+	assert.Contains(t, string(content), `&lt;b&gt;`)
+	assert.Contains(t, string(content), `<a href="sitemap-2.html" rel="next">`)
+}
+
+// TestHTMLSitemapFromSitemapIndex tests rendering the Sitemaps of a SitemapIndex grouped by sections
+func TestHTMLSitemapFromSitemapIndex(t *testing.T) {
+	smi := NewSitemapIndex(true)
+	smi.SetHostname(baseURL)
+
+	blog := smi.NewSitemap()
+	blog.SetName("blog")
+	news := smi.NewSitemap()
+	news.SetName("news")
+	news.SetFormat(FormatText)
+	for _, route := range []string{"/b1", "/b2"} {
+		err := blog.Add(&SitemapLoc{Loc: route, Images: []*SitemapImage{{"/image.jpg"}}})
+		if err != nil {
+			t.Fatal("Unable to add SitemapLoc:", err)
+		}
+	}
+	err := news.Add(&SitemapLoc{Loc: "/n1"})
+	if err != nil {
+		t.Fatal("Unable to add SitemapLoc:", err)
+	}
+
+	hs := NewHTMLSitemap()
+	hs.SetGrouping(GroupSection)
+	hs.SetTemplate(template.Must(template.New("custom").Parse(
+		`{{range .Groups}}[{{.Name}}]{{range .Links}} {{.Loc}}{{end}}{{end}}`)))
+	err = hs.AddSitemapIndex(smi)
+	if err != nil {
+		t.Fatal("Unable to add SitemapIndex:", err)
+	}
+
+	pages := hs.Pages()
+	assert.Len(t, pages, 1)
+	buf := strings.Builder{}
+	err = hs.template.Execute(&buf, pages[0])
+	if err != nil {
+		t.Fatal("Unable to execute template:", err)
+	}
+	assert.Equal(t, "[blog] "+baseURL+"/b1 "+baseURL+"/b2[news] "+baseURL+"/n1", buf.String())
+	assert.Equal(t, baseURL+"/image.jpg", pages[0].Groups[0].Links[0].Images[0].ImageLoc)
+}
@@ -0,0 +1,65 @@
+package smg
+
+import "time"
+
+// TimePrecision is used for defining the W3C Datetime precision of
+// lastmod values in Sitemap and SitemapIndex.
+// See https://www.w3.org/TR/NOTE-datetime for the complete format.
+type TimePrecision int
+
+// predefined TimePrecision values
+const (
+	// PrecisionDefault keeps the encoding/xml default output which is
+	// RFC3339 with nanoseconds, e.g. 2022-02-12T16:29:46.45013Z
+	PrecisionDefault TimePrecision = iota
+	// PrecisionDate formats lastmod as YYYY-MM-DD
+	PrecisionDate
+	// PrecisionMinutes formats lastmod as YYYY-MM-DDThh:mmTZD
+	PrecisionMinutes
+	// PrecisionSeconds formats lastmod as YYYY-MM-DDThh:mm:ssTZD
+	PrecisionSeconds
+)
+
+// layout returns the time layout of the TimePrecision.
+func (p TimePrecision) layout() string {
+	switch p {
+	case PrecisionDate:
+		return "2006-01-02"
+	case PrecisionMinutes:
+		return "2006-01-02T15:04Z07:00"
+	case PrecisionSeconds:
+		return "2006-01-02T15:04:05Z07:00"
+	default:
+		return time.RFC3339Nano
+	}
+}
+
+// parseLastMod parses a lastmod value in any of the W3C Datetime precisions
+// which are supported by TimePrecision. returns nil in case of empty value.
+func parseLastMod(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var err error
+	for _, precision := range []TimePrecision{PrecisionDefault, PrecisionMinutes, PrecisionDate} {
+		var t time.Time
+		t, err = time.Parse(precision.layout(), value)
+		if err == nil {
+			return &t, nil
+		}
+	}
+	return nil, err
+}
+
+// formatLastMod formats the t using the lastmod precision and location
+// of Options. returns an empty string in case of nil t.
+func (o *Options) formatLastMod(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	lastMod := *t
+	if o.lastModLocation != nil {
+		lastMod = lastMod.In(o.lastModLocation)
+	}
+	return lastMod.Format(o.lastModPrecision.layout())
+}
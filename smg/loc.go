@@ -6,6 +6,9 @@ import (
 )
 
 // SitemapLoc contains data related to <url> tag in Sitemap.
+// Extensions are the custom elements which are appended to <url>. Their names must be
+// in namespaces registered by RegisterNamespace, which are declared only on the urlset
+// tag of the Sitemap files which use them. They are ignored by text format.
 type SitemapLoc struct {
 	XMLName    xml.Name        `xml:"url"`
 	Loc        string          `xml:"loc"`
@@ -13,6 +16,7 @@ type SitemapLoc struct {
 	ChangeFreq ChangeFreq      `xml:"changefreq,omitempty"`
 	Priority   float32         `xml:"priority,omitempty"`
 	Images     []*SitemapImage `xml:"image:image,omitempty"`
+	Extensions []xml.Marshaler `xml:"-"`
 }
 
 // SitemapImage contains data related to <image:image> tag in Sitemap <url>
@@ -26,3 +30,30 @@ type SitemapIndexLoc struct {
 	Loc     string     `xml:"loc"`
 	LastMod *time.Time `xml:"lastmod,omitempty"`
 }
+
+// xmlSitemapLoc is the encoded form of SitemapLoc which has
+// the LastMod formatted based on the Options.
+type xmlSitemapLoc struct {
+	XMLName    xml.Name        `xml:"url"`
+	Loc        string          `xml:"loc"`
+	LastMod    string          `xml:"lastmod,omitempty"`
+	ChangeFreq ChangeFreq      `xml:"changefreq,omitempty"`
+	Priority   float32         `xml:"priority,omitempty"`
+	Images     []*SitemapImage `xml:"image:image,omitempty"`
+	Extensions []xmlExtension  `xml:",omitempty"`
+}
+
+// xmlSitemapIndexLoc is the encoded form of SitemapIndexLoc which has
+// the LastMod formatted based on the Options.
+type xmlSitemapIndexLoc struct {
+	XMLName xml.Name `xml:"sitemap"`
+	Loc     string   `xml:"loc"`
+	LastMod string   `xml:"lastmod,omitempty"`
+}
+
+// xmlSitemapIndex is the encoded form of SitemapIndex.
+type xmlSitemapIndex struct {
+	XMLName     xml.Name              `xml:"sitemapindex"`
+	Xmlns       string                `xml:"xmlns,attr"`
+	SitemapLocs []*xmlSitemapIndexLoc `xml:"sitemap"`
+}
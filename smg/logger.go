@@ -0,0 +1,27 @@
+package smg
+
+// Logger is used for logging the events of Sitemap, SitemapIndex and DynamicHandler.
+// args are alternating keys and values. The methods have the same signatures as the
+// methods of *slog.Logger, so it can be used directly. The default Logger is silent.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// nopLogger is the default Logger which discards all events.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// logger returns the Logger of Options or the silent default Logger in case of absence.
+func (o *Options) logger() Logger {
+	if o.log == nil {
+		return nopLogger{}
+	}
+	return o.log
+}
@@ -0,0 +1,85 @@
+package smg
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordLogger is a Logger which records the events.
+type recordLogger struct {
+	events []string
+	mutex  sync.Mutex
+}
+
+func (l *recordLogger) record(level, msg string, args ...interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.events = append(l.events, strings.TrimSpace(fmt.Sprintln(append([]interface{}{level, msg}, args...)...)))
+}
+
+func (l *recordLogger) Debug(msg string, args ...interface{}) { l.record("DEBUG", msg, args...) }
+func (l *recordLogger) Info(msg string, args ...interface{})  { l.record("INFO", msg, args...) }
+func (l *recordLogger) Warn(msg string, args ...interface{})  { l.record("WARN", msg, args...) }
+func (l *recordLogger) Error(msg string, args ...interface{}) { l.record("ERROR", msg, args...) }
+
+// failingStorage is a Storage which fails to write any file.
+type failingStorage struct{}
+
+func (failingStorage) WriteFile(string, string, []byte) error {
+	return errors.New("no space left on device")
+}
+
+// TestSitemapIndexLoggerAndClock tests that the Logger and clock are used by SitemapIndex and it's Sitemaps
+func TestSitemapIndexLoggerAndClock(t *testing.T) {
+	logger := &recordLogger{}
+	now := time.Date(2022, 2, 12, 16, 29, 46, 0, time.UTC)
+	storage := NewMemoryStorage()
+	storage.SetClock(FixedClock(now))
+	smi := NewSitemapIndex(false)
+	smi.SetHostname(baseURL)
+	smi.SetStorage(storage)
+	smi.SetCompress(false)
+	smi.SetLogger(logger)
+	smi.SetClock(FixedClock(now))
+
+	sm := smi.NewSitemap()
+	sm.SetMaxURLsCount(1)
+	assert.NoError(t, sm.Add(&SitemapLoc{Loc: "/a"}))
+	assert.NoError(t, sm.Add(&SitemapLoc{Loc: "/b"}))
+	_, err := smi.Save()
+	if err != nil {
+		t.Fatal("Unable to Save SitemapIndex:", err)
+	}
+
+	assert.Equal(t, []string{
+		"DEBUG sitemap is split name sitemap1 part 2 urls 1",
+		"DEBUG sitemap is saved filename sitemap1.xml urls 1",
+		"DEBUG sitemap is saved filename sitemap1-2.xml urls 1",
+		"INFO sitemap index is saved filename sitemap.xml sitemaps 2",
+	}, logger.events)
+	assert.Nil(t, smi.SitemapLocs[0].LastMod)
+
+	f, err := storage.Open("sitemap.xml")
+	if err != nil {
+		t.Fatal("Unable to open sitemap index:", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal("Unable to stat sitemap index:", err)
+	}
+	assert.Equal(t, now, info.ModTime())
+
+	// the errors of Sitemaps are logged and returned
+	logger.events = nil
+	smi.Sitemaps[0].SetStorage(failingStorage{})
+	_, err = smi.Save()
+	assert.Error(t, err)
+	assert.Len(t, logger.events, 1)
+	assert.True(t, strings.HasPrefix(logger.events[0], "ERROR error while saving sitemap"))
+}
@@ -0,0 +1,115 @@
+package smg
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"time"
+)
+
+const manifestFileExt string = ".json"
+
+// predefined ManifestFile types
+const (
+	ManifestSitemapIndex = "sitemapindex"
+	ManifestSitemap      = "sitemap"
+	ManifestStylesheet   = "stylesheet"
+)
+
+// Manifest describes the files which are written by SitemapIndex.Save method.
+// It is saved as a JSON file alongside them in case of SitemapIndex.SetManifestName.
+type Manifest struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	URLCount    int             `json:"url_count"`
+	Files       []*ManifestFile `json:"files"`
+}
+
+// ManifestFile describes a written file of Manifest. URLCount is the number of URLs of a
+// Sitemap file or the number of entries of the SitemapIndex file. RawSize is the uncompressed
+// size and GzipSize is the gzip compressed size of every file, either as written or as it would
+// be compressed for uncompressed files. SHA256 is the checksum of the file as written.
+// MinLastMod and MaxLastMod are the range of lastmod values of the URLs of a Sitemap file.
+type ManifestFile struct {
+	Filename   string     `json:"filename"`
+	URL        string     `json:"url"`
+	Type       string     `json:"type"`
+	URLCount   int        `json:"url_count"`
+	RawSize    int        `json:"raw_size"`
+	GzipSize   int        `json:"gzip_size"`
+	SHA256     string     `json:"sha256"`
+	MinLastMod *time.Time `json:"min_lastmod,omitempty"`
+	MaxLastMod *time.Time `json:"max_lastmod,omitempty"`
+}
+
+// addFile appends the saved file into Manifest using the public URL of it.
+func (m *Manifest) addFile(o *Options, fileType string, file *savedFile, urlsCount int, minLastMod, maxLastMod *time.Time) error {
+	loc, err := publicURL(o.Hostname, o.ServerURI, file.filename)
+	if err != nil {
+		return err
+	}
+	mf := &ManifestFile{
+		Filename:   file.filename,
+		URL:        loc,
+		Type:       fileType,
+		URLCount:   urlsCount,
+		RawSize:    file.rawSize,
+		GzipSize:   file.gzipSize,
+		SHA256:     file.sha256,
+		MinLastMod: minLastMod,
+		MaxLastMod: maxLastMod,
+	}
+	m.Files = append(m.Files, mf)
+	return nil
+}
+
+// buildManifest builds the Manifest of the last saved files of SitemapIndex and it's Sitemaps.
+func (s *SitemapIndex) buildManifest() (*Manifest, error) {
+	m := &Manifest{
+		GeneratedAt: s.Now().UTC(),
+		Files:       make([]*ManifestFile, 0),
+	}
+	for _, file := range s.savedFiles {
+		err := m.addFile(&s.Options, ManifestSitemapIndex, file, len(s.SitemapLocs), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, sitemap := range s.Sitemaps {
+		for sm := sitemap; sm != nil; sm = sm.NextSitemap {
+			if len(sm.savedFiles) == 0 {
+				continue
+			}
+			for _, file := range sm.savedFiles {
+				err := m.addFile(&sm.Options, ManifestSitemap, file, sm.urlsCount, sm.minLastMod, sm.maxLastMod)
+				if err != nil {
+					return nil, err
+				}
+			}
+			m.URLCount += sm.urlsCount
+		}
+	}
+	for _, file := range s.xslFiles {
+		err := m.addFile(&s.Options, ManifestStylesheet, file, 0, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// saveManifest builds and saves the Manifest of SitemapIndex as a JSON file.
+func (s *SitemapIndex) saveManifest() error {
+	m, err := s.buildManifest()
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = writeToFile(s.fileStorage(), s.manifestName+manifestFileExt, s.OutputPath, gzip.NoCompression, content, []byte{'\n'})
+	if err != nil {
+		return err
+	}
+	s.manifest = m
+	return nil
+}
@@ -0,0 +1,118 @@
+package smg
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSitemapIndexManifest tests saving the JSON manifest of written files
+func TestSitemapIndexManifest(t *testing.T) {
+	storage := NewMemoryStorage()
+	smi := NewSitemapIndex(false)
+	smi.SetHostname(baseURL)
+	smi.SetServerURI("/sitemaps/")
+	smi.SetStorage(storage)
+	smi.SetManifestName("manifest")
+
+	first := time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC)
+	last := time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+	sm := smi.NewSitemap()
+	sm.SetMaxURLsCount(2)
+	for _, u := range []*SitemapLoc{
+		{Loc: "/a", LastMod: &last},
+		{Loc: "/b", LastMod: &first},
+		{Loc: "/c"},
+	} {
+		assert.NoError(t, sm.Add(u))
+	}
+	_, err := smi.Save()
+	if err != nil {
+		t.Fatal("Unable to Save SitemapIndex:", err)
+	}
+
+	content, err := storage.ReadFile("manifest.json")
+	if err != nil {
+		t.Fatal("Unable to read manifest:", err)
+	}
+	var m Manifest
+	err = json.Unmarshal(content, &m)
+	if err != nil {
+		t.Fatal("Unable to decode manifest:", err)
+	}
+	assert.Equal(t, 3, m.URLCount)
+	assert.False(t, m.GeneratedAt.IsZero())
+	assert.Len(t, m.Files, 3)
+
+	index := m.Files[0]
+	assert.Equal(t, ManifestSitemapIndex, index.Type)
+	assert.Equal(t, "sitemap.xml.gz", index.Filename)
+	assert.Equal(t, baseURL+"/sitemaps/sitemap.xml.gz", index.URL)
+	assert.Equal(t, 2, index.URLCount)
+
+	part := m.Files[1]
+	assert.Equal(t, ManifestSitemap, part.Type)
+	assert.Equal(t, "sitemap1.xml.gz", part.Filename)
+	assert.Equal(t, 2, part.URLCount)
+	assert.Equal(t, first, *part.MinLastMod)
+	assert.Equal(t, last, *part.MaxLastMod)
+	assert.Nil(t, m.Files[2].MinLastMod)
+
+	written, err := storage.ReadFile(part.Filename)
+	if err != nil {
+		t.Fatal("Unable to read sitemap:", err)
+	}
+	sum := sha256.Sum256(written)
+	assert.Equal(t, hex.EncodeToString(sum[:]), part.SHA256)
+	assert.Equal(t, len(written), part.GzipSize)
+	assert.Greater(t, part.RawSize, part.GzipSize)
+	assert.Equal(t, m.Files, smi.Manifest().Files)
+}
+
+// TestSitemapIndexManifestUncompressed tests that the gzip sizes of uncompressed files are in the manifest
+func TestSitemapIndexManifestUncompressed(t *testing.T) {
+	storage := NewMemoryStorage()
+	smi := NewSitemapIndex(false)
+	smi.SetHostname(baseURL)
+	smi.SetStorage(storage)
+	smi.SetCompress(false)
+	smi.SetDefaultXSLStylesheets()
+	smi.SetManifestName("manifest")
+	sm := smi.NewSitemap()
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, sm.Add(&SitemapLoc{Loc: fmt.Sprintf("/item/%d", i)}))
+	}
+	_, err := smi.Save()
+	if err != nil {
+		t.Fatal("Unable to Save SitemapIndex:", err)
+	}
+
+	m := smi.Manifest()
+	assert.Len(t, m.Files, 4)
+	for _, file := range m.Files {
+		written, err := storage.ReadFile(file.Filename)
+		if err != nil {
+			t.Fatal("Unable to read file:", err)
+		}
+		assert.Equal(t, len(written), file.RawSize, file.Filename)
+		assert.Equal(t, compressedLen(gzip.DefaultCompression, written), file.GzipSize, file.Filename)
+		assert.Greater(t, file.RawSize, file.GzipSize, file.Filename)
+	}
+
+	// the gzip size is the size of the compressed variant in case of writing both variants
+	smi.SetWriteBothVariants(true)
+	_, err = smi.Save()
+	if err != nil {
+		t.Fatal("Unable to Save SitemapIndex:", err)
+	}
+	files := smi.Manifest().Files
+	assert.Equal(t, "sitemap.xml", files[0].Filename)
+	assert.Equal(t, "sitemap.xml.gz", files[1].Filename)
+	assert.Equal(t, files[1].GzipSize, files[0].GzipSize)
+}
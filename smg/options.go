@@ -1,14 +1,531 @@
 package smg
 
+import (
+	"compress/gzip"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
 // Options contains general attributes of Sitemap and SitemapIndex.
 // OutputPath is the dir path to save the SitemapIndex file and it's
 // sitemaps. Name of Sitemap output xml file which must be without ".xml" extension.
 // Hostname of Sitemap urls which be prepended to all URLs. Compress option can be
-// either enabled or disabled for Sitemap and SitemapIndex.
+// either enabled or disabled for Sitemap and SitemapIndex. ServerURI is the path
+// of saved files on the server which is used for making their public URLs.
 type Options struct {
-	Compress    bool   `xml:"-"`
-	Name        string `xml:"-"`
-	Hostname    string `xml:"-"`
-	OutputPath  string `xml:"-"`
-	prettyPrint bool
+	Compress         bool   `xml:"-"`
+	Name             string `xml:"-"`
+	Hostname         string `xml:"-"`
+	OutputPath       string `xml:"-"`
+	ServerURI        string `xml:"-"`
+	prettyPrint      bool
+	lastModPrecision TimePrecision
+	lastModLocation  *time.Location
+	xslTag           string
+	saveDefaultXSL   bool
+	format           Format
+	storage          Storage
+	filenameTemplate filenameTemplate
+	hashFilenames    bool
+	gzipLevel        int
+	bothVariants     bool
+	now              func() time.Time
+	log              Logger
+	indent           string
+	namespaces       []xmlNamespace
+}
+
+// fileStorage returns the Storage of Options or the default
+// local file system storage in case of absence.
+func (o *Options) fileStorage() Storage {
+	if o.storage == nil {
+		return dirStorage{}
+	}
+	return o.storage
+}
+
+// indentation returns the indent of pretty printed xml files which is two spaces by default.
+func (o *Options) indentation() string {
+	if o.indent == "" {
+		return "  "
+	}
+	return o.indent
+}
+
+// Now returns the time of the clock of Options or time.Now in case of absence.
+// It is used by the sources which set lastmod values, so they follow SetClock.
+func (o *Options) Now() time.Time {
+	if o.now == nil {
+		return time.Now()
+	}
+	return o.now()
+}
+
+// clockStorage is implemented by the Storages which have a clock for the modification times of files.
+type clockStorage interface {
+	SetClock(now func() time.Time)
+}
+
+// passClock sets the clock of Options as the clock of Storage which has a clock, e.g. MemoryStorage.
+// The clock of Storage is kept in case of absence.
+func (o *Options) passClock() {
+	if storage, ok := o.storage.(clockStorage); ok && o.now != nil {
+		storage.SetClock(o.now)
+	}
+}
+
+// FixedClock returns a clock which always returns t. It is used with SetClock methods
+// for reproducible builds which write the same bytes for the same input.
+func FixedClock(t time.Time) func() time.Time {
+	return func() time.Time {
+		return t
+	}
+}
+
+// compressionLevel returns the gzip level of Options which is gzip.DefaultCompression
+// in case of absence or gzip.NoCompression in case of disabled Compress.
+func (o *Options) compressionLevel(compress bool) int {
+	switch {
+	case !compress:
+		return gzip.NoCompression
+	case o.gzipLevel == 0:
+		return gzip.DefaultCompression
+	default:
+		return o.gzipLevel
+	}
+}
+
+// checkCompressionLevel returns an error in case of an unsupported gzip level.
+func checkCompressionLevel(level int) error {
+	if level == gzip.NoCompression {
+		return fmt.Errorf("gzip level %d is not supported, disable Compress for uncompressed files", level)
+	}
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		return fmt.Errorf("invalid gzip level %d", level)
+	}
+	return nil
+}
+
+// variantFilenames returns the filename which is the designated variant by Compress
+// and the filename of the other variant in case of writing both variants.
+func (o *Options) variantFilenames(filename string) []string {
+	if !o.bothVariants {
+		return []string{filename}
+	}
+	if o.Compress {
+		return []string{filename, strings.TrimSuffix(filename, ".gz")}
+	}
+	return []string{filename, filename + ".gz"}
+}
+
+// writeFile uses the Storage to write the content as filename into OutputPath.
+// filename is the designated variant by Compress and the other variant is written
+// as well in case of writing both variants. returns the written files in order.
+func (o *Options) writeFile(filename string, content ...[]byte) ([]*savedFile, error) {
+	filenames := o.variantFilenames(filename)
+	files := make([]*savedFile, len(filenames))
+	for i, name := range filenames {
+		compress := o.Compress
+		if i > 0 {
+			compress = !compress
+		}
+		var err error
+		files[i], err = writeToFile(o.fileStorage(), name, o.OutputPath, o.compressionLevel(compress), content...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// the gzip size of uncompressed files is taken from the compressed variant or computed
+	for _, file := range files {
+		if file.compressed {
+			continue
+		}
+		for _, other := range files {
+			if other.compressed {
+				file.gzipSize = other.gzipSize
+			}
+		}
+		if file.gzipSize == 0 {
+			file.gzipSize = compressedLen(o.compressionLevel(true), content...)
+		}
+	}
+	return files, nil
+}
+
+// settings collects the values of Option functions which are used
+// by NewSitemapWithOptions and NewSitemapIndexWithOptions.
+type settings struct {
+	Options
+	maxURLsCount int
+	maxFileBytes int
+	maxGzipBytes int
+	manifestName string
+	shardFunc    ShardFunc
+}
+
+// Option is a setting of NewSitemapWithOptions and NewSitemapIndexWithOptions
+// which is validated before building the Sitemap or SitemapIndex.
+// The settings of SitemapIndex are applied to all of it's Sitemaps and their parts.
+type Option func(s *settings) error
+
+// newSettings applies the opts to the default settings.
+func newSettings(opts []Option) (*settings, error) {
+	s := &settings{
+		maxURLsCount: MaxURLsCount,
+		maxFileBytes: MaxFileSize,
+	}
+	s.Name = "sitemap"
+	s.Compress = true
+	for _, opt := range opts {
+		err := opt(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if s.xslTag != "" && s.saveDefaultXSL {
+		return nil, fmt.Errorf("XSL stylesheet conflicts with the default XSL stylesheets")
+	}
+	if s.format == FormatText && (s.xslTag != "" || s.saveDefaultXSL) {
+		return nil, fmt.Errorf("XSL stylesheets are not supported by the text format")
+	}
+	return s, nil
+}
+
+// NewSitemapWithOptions builds and returns a new Sitemap using the opts,
+// or an error in case of any invalid option.
+func NewSitemapWithOptions(opts ...Option) (*Sitemap, error) {
+	st, err := newSettings(opts)
+	if err != nil {
+		return nil, err
+	}
+	if st.manifestName != "" {
+		return nil, fmt.Errorf("manifest is supported only by SitemapIndex")
+	}
+	if st.shardFunc != nil {
+		return nil, fmt.Errorf("shard strategy is supported only by SitemapIndex")
+	}
+	return st.newSitemap()
+}
+
+// newSitemap builds a new Sitemap using the settings.
+func (st *settings) newSitemap() (*Sitemap, error) {
+	sm := NewSitemap(false)
+	sm.Options = st.Options
+	sm.setIndent(st.indent)
+	if st.now != nil {
+		sm.SetClock(st.now)
+	}
+	if st.saveDefaultXSL {
+		sm.SetDefaultXSLStylesheet()
+	}
+	sm.SetMaxURLsCount(st.maxURLsCount)
+	err := sm.SetMaxFileSize(st.maxFileBytes)
+	if err != nil {
+		return nil, err
+	}
+	err = sm.SetMaxCompressedSize(st.maxGzipBytes)
+	if err != nil {
+		return nil, err
+	}
+	return sm, nil
+}
+
+// NewSitemapIndexWithOptions builds and returns a new SitemapIndex using the opts,
+// or an error in case of any invalid option. The Sitemaps which are built using
+// NewSitemap method have the same settings except their Name.
+func NewSitemapIndexWithOptions(opts ...Option) (*SitemapIndex, error) {
+	st, err := newSettings(opts)
+	if err != nil {
+		return nil, err
+	}
+	// validates the limits using a Sitemap with the same header
+	_, err = st.newSitemap()
+	if err != nil {
+		return nil, err
+	}
+	s := NewSitemapIndex(false)
+	s.Options = st.Options
+	s.passClock()
+	if st.saveDefaultXSL {
+		s.SetDefaultXSLStylesheets()
+	}
+	s.maxURLsCount = st.maxURLsCount
+	s.maxFileBytes = st.maxFileBytes
+	s.maxGzipBytes = st.maxGzipBytes
+	s.manifestName = st.manifestName
+	s.shardFunc = st.shardFunc
+	return s, nil
+}
+
+// WithIndent enables pretty printing of xml files using the indent which must contain
+// only spaces and tabs, e.g. "  " or "\t". Empty indent disables it which is the default.
+func WithIndent(indent string) Option {
+	return func(s *settings) error {
+		if strings.Trim(indent, " \t") != "" {
+			return fmt.Errorf("indent %q must contain only spaces and tabs", indent)
+		}
+		s.prettyPrint = indent != ""
+		s.indent = indent
+		return nil
+	}
+}
+
+// WithCompress enables or disables gzip compression of files. Default is enabled.
+func WithCompress(compress bool) Option {
+	return func(s *settings) error {
+		s.Compress = compress
+		return nil
+	}
+}
+
+// WithCompressionLevel sets the gzip level of compressed files. See Sitemap.SetCompressionLevel.
+func WithCompressionLevel(level int) Option {
+	return func(s *settings) error {
+		err := checkCompressionLevel(level)
+		if err != nil {
+			return err
+		}
+		s.gzipLevel = level
+		return nil
+	}
+}
+
+// WithName sets the Name of Sitemap or SitemapIndex file which must be without extension.
+func WithName(name string) Option {
+	return func(s *settings) error {
+		if name == "" || strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("invalid name %q", name)
+		}
+		s.Name = name
+		return nil
+	}
+}
+
+// WithHostname sets the Hostname which must be an absolute http or https URL.
+func WithHostname(hostname string) Option {
+	return func(s *settings) error {
+		u, err := url.Parse(hostname)
+		if err != nil {
+			return fmt.Errorf("invalid hostname %q: %w", hostname, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("hostname %q must be an absolute http or https URL", hostname)
+		}
+		s.Hostname = hostname
+		return nil
+	}
+}
+
+// WithOutputPath sets the OutputPath which must not be an existing file.
+func WithOutputPath(outputPath string) Option {
+	return func(s *settings) error {
+		if info, err := os.Stat(outputPath); err == nil && !info.IsDir() {
+			return fmt.Errorf("output path %q is not a directory", outputPath)
+		}
+		s.OutputPath = outputPath
+		return nil
+	}
+}
+
+// WithServerURI sets the ServerURI which must be a path without scheme and host.
+func WithServerURI(serverURI string) Option {
+	return func(s *settings) error {
+		u, err := url.Parse(serverURI)
+		if err != nil {
+			return fmt.Errorf("invalid server URI %q: %w", serverURI, err)
+		}
+		if u.Scheme != "" || u.Host != "" {
+			return fmt.Errorf("server URI %q must be a path", serverURI)
+		}
+		s.ServerURI = serverURI
+		return nil
+	}
+}
+
+// WithStorage sets the Storage which is used for writing the files.
+func WithStorage(storage Storage) Option {
+	return func(s *settings) error {
+		if storage == nil {
+			return fmt.Errorf("storage must not be nil")
+		}
+		s.storage = storage
+		return nil
+	}
+}
+
+// WithMaxURLsCount sets the maximum # of URLs of each part which must be between 1 and 50,000.
+func WithMaxURLsCount(maxURLsCount int) Option {
+	return func(s *settings) error {
+		if maxURLsCount < 1 || maxURLsCount > MaxURLsCount {
+			return fmt.Errorf("max URLs count %d must be between 1 and %d", maxURLsCount, MaxURLsCount)
+		}
+		s.maxURLsCount = maxURLsCount
+		return nil
+	}
+}
+
+// WithMaxFileSize sets the maximum uncompressed size of each part. See Sitemap.SetMaxFileSize.
+func WithMaxFileSize(size int) Option {
+	return func(s *settings) error {
+		if size < 1 || size > MaxFileSize {
+			return fmt.Errorf("max file size %d must be between 1 and %d bytes", size, MaxFileSize)
+		}
+		s.maxFileBytes = size
+		return nil
+	}
+}
+
+// WithMaxCompressedSize sets the maximum compressed size of each part. See Sitemap.SetMaxCompressedSize.
+func WithMaxCompressedSize(size int) Option {
+	return func(s *settings) error {
+		if size < 0 {
+			return fmt.Errorf("max compressed file size %d must not be negative", size)
+		}
+		s.maxGzipBytes = size
+		return nil
+	}
+}
+
+// WithNamespace registers an XML namespace using the prefix for the Extensions of SitemapLoc.
+// The prefix must be a valid XML name which is not reserved and the uri must be an absolute URI.
+// See Sitemap.RegisterNamespace.
+func WithNamespace(prefix, uri string) Option {
+	return func(s *settings) error {
+		registered, err := checkNamespace(s.namespaces, prefix, uri)
+		if err != nil {
+			return err
+		}
+		if !registered {
+			s.namespaces = append(s.namespaces, xmlNamespace{prefix: prefix, uri: uri})
+		}
+		return nil
+	}
+}
+
+// WithLastModPrecision sets the W3C Datetime precision of lastmod values
+// which must be one of the predefined TimePrecision values.
+func WithLastModPrecision(precision TimePrecision) Option {
+	return func(s *settings) error {
+		if precision < PrecisionDefault || precision > PrecisionSeconds {
+			return fmt.Errorf("unknown lastmod precision %d", precision)
+		}
+		s.lastModPrecision = precision
+		return nil
+	}
+}
+
+// WithLastModLocation sets the timezone which lastmod values are converted to.
+func WithLastModLocation(loc *time.Location) Option {
+	return func(s *settings) error {
+		s.lastModLocation = loc
+		return nil
+	}
+}
+
+// WithFilenameTemplate sets the filename template of Sitemap parts. See Sitemap.SetFilenameTemplate.
+func WithFilenameTemplate(template string) Option {
+	return func(s *settings) error {
+		t, err := parseFilenameTemplate(template)
+		if err != nil {
+			return err
+		}
+		s.filenameTemplate = t
+		return nil
+	}
+}
+
+// WithHashFilenames enables content hash filenames. See Sitemap.SetHashFilenames.
+func WithHashFilenames(enabled bool) Option {
+	return func(s *settings) error {
+		s.hashFilenames = enabled
+		return nil
+	}
+}
+
+// WithWriteBothVariants enables writing both compressed and uncompressed files. See Sitemap.SetWriteBothVariants.
+func WithWriteBothVariants(enabled bool) Option {
+	return func(s *settings) error {
+		s.bothVariants = enabled
+		return nil
+	}
+}
+
+// WithFormat sets the output Format of Sitemaps which must be one of the predefined Format values.
+// SitemapIndex files are always XML. See Sitemap.SetFormat.
+func WithFormat(format Format) Option {
+	return func(s *settings) error {
+		if format != FormatXML && format != FormatText {
+			return fmt.Errorf("unknown format %d", format)
+		}
+		s.format = format
+		return nil
+	}
+}
+
+// WithXSLStylesheet sets the href of an XSL stylesheet of the Sitemap or SitemapIndex file
+// which must be a valid URI reference. It does not change the Sitemaps of SitemapIndex.
+// See Sitemap.SetXSLStylesheet.
+func WithXSLStylesheet(href string) Option {
+	return func(s *settings) error {
+		if href == "" {
+			return fmt.Errorf("XSL stylesheet href must not be empty")
+		}
+		if _, err := url.Parse(href); err != nil {
+			return fmt.Errorf("invalid XSL stylesheet href %q: %w", href, err)
+		}
+		s.xslTag = xslStylesheetTag(href)
+		return nil
+	}
+}
+
+// WithDefaultXSLStylesheets enables the embedded default XSL stylesheets.
+// See SitemapIndex.SetDefaultXSLStylesheets and Sitemap.SetDefaultXSLStylesheet.
+func WithDefaultXSLStylesheets() Option {
+	return func(s *settings) error {
+		s.saveDefaultXSL = true
+		return nil
+	}
+}
+
+// WithManifestName enables saving a JSON Manifest of SitemapIndex using the name which
+// must be without extension. See SitemapIndex.SetManifestName.
+func WithManifestName(name string) Option {
+	return func(s *settings) error {
+		if name == "" || strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("invalid manifest name %q", name)
+		}
+		s.manifestName = name
+		return nil
+	}
+}
+
+// WithShardStrategy sets the ShardFunc of SitemapIndex which routes the URLs of AddURL
+// method into Sitemaps. See SitemapIndex.SetShardStrategy.
+func WithShardStrategy(shardFunc ShardFunc) Option {
+	return func(s *settings) error {
+		if shardFunc == nil {
+			return fmt.Errorf("shard strategy must not be nil")
+		}
+		s.shardFunc = shardFunc
+		return nil
+	}
+}
+
+// WithClock sets the clock which is used instead of time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *settings) error {
+		s.now = now
+		return nil
+	}
+}
+
+// WithLogger sets the Logger which is silent by default.
+func WithLogger(logger Logger) Option {
+	return func(s *settings) error {
+		s.log = logger
+		return nil
+	}
 }
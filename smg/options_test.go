@@ -0,0 +1,202 @@
+package smg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewSitemapIndexWithOptions tests that the options are applied to all Sitemaps and their parts
+func TestNewSitemapIndexWithOptions(t *testing.T) {
+	storage := NewMemoryStorage()
+	smi, err := NewSitemapIndexWithOptions(
+		WithName("index"),
+		WithHostname(baseURL),
+		WithServerURI("/sitemaps/"),
+		WithStorage(storage),
+		WithCompress(false),
+		WithIndent("\t"),
+		WithMaxURLsCount(1),
+		WithNamespace("xhtml", "http://www.w3.org/1999/xhtml"),
+	)
+	if err != nil {
+		t.Fatal("Unable to build SitemapIndex:", err)
+	}
+	sm := smi.NewSitemap()
+	assert.NoError(t, sm.Add(&SitemapLoc{Loc: "/a"}))
+	assert.NoError(t, sm.Add(&SitemapLoc{Loc: "/b"}))
+	assert.NotNil(t, sm.NextSitemap)
+
+	filename, err := smi.Save()
+	if err != nil {
+		t.Fatal("Unable to Save SitemapIndex:", err)
+	}
+	assert.Equal(t, "index.xml", filename)
+	index, err := storage.ReadFile("index.xml")
+	if err != nil {
+		t.Fatal("Unable to read sitemap index:", err)
+	}
+	assert.Contains(t, string(index), "\n\t<sitemap>\n\t\t<loc>"+baseURL+"/sitemaps/sitemap1-2.xml</loc>")
+
+	for _, filename := range []string{"sitemap1.xml", "sitemap1-2.xml"} {
+		content, err := storage.ReadFile(filename)
+		if err != nil {
+			t.Fatal("Unable to read sitemap:", err)
+		}
+		// the registered namespace is not declared since it is not used by any URL
+		assert.NotContains(t, string(content), `xmlns:xhtml`, filename)
+		assert.Contains(t, string(content), "<url>\n\t<loc>", filename)
+	}
+}
+
+// TestInvalidOptions tests that the invalid options are reported by the constructors
+func TestInvalidOptions(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"indent":       WithIndent("--"),
+		"name":         WithName("a/b"),
+		"hostname":     WithHostname("www.example.com"),
+		"server URI":   WithServerURI("https://www.example.com/sitemaps/"),
+		"storage":      WithStorage(nil),
+		"max URLs":     WithMaxURLsCount(50001),
+		"max size":     WithMaxFileSize(MaxFileSize + 1),
+		"small size":   WithMaxFileSize(100),
+		"gzip size":    WithMaxCompressedSize(-1),
+		"gzip level":   WithCompressionLevel(0),
+		"template":     WithFilenameTemplate("{unknown}"),
+		"prefix":       WithNamespace("image", "http://www.example.com/ns"),
+		"xml prefix":   WithNamespace("xmlfoo", "http://www.example.com/ns"),
+		"relative uri": WithNamespace("foo", "ns"),
+		"precision":    WithLastModPrecision(PrecisionSeconds + 1),
+		"format":       WithFormat(FormatText + 1),
+		"stylesheet":   WithXSLStylesheet(""),
+		"xsl href":     WithXSLStylesheet("%zz"),
+		"manifest":     WithManifestName("a/b"),
+		"shard":        WithShardStrategy(nil),
+	} {
+		_, err := NewSitemapWithOptions(opt)
+		assert.Error(t, err, name)
+		_, err = NewSitemapIndexWithOptions(opt)
+		assert.Error(t, err, name)
+	}
+
+	_, err := NewSitemapWithOptions(WithNamespace("a", "http://a"), WithNamespace("a", "http://b"))
+	assert.Error(t, err)
+
+	for name, opts := range map[string][]Option{
+		"text stylesheet":    {WithFormat(FormatText), WithXSLStylesheet("/sitemap.xsl")},
+		"text default":       {WithFormat(FormatText), WithDefaultXSLStylesheets()},
+		"stylesheet default": {WithXSLStylesheet("/sitemap.xsl"), WithDefaultXSLStylesheets()},
+	} {
+		_, err = NewSitemapWithOptions(opts...)
+		assert.Error(t, err, name)
+		_, err = NewSitemapIndexWithOptions(opts...)
+		assert.Error(t, err, name)
+	}
+
+	// the options of SitemapIndex only
+	_, err = NewSitemapWithOptions(WithManifestName("manifest"))
+	assert.Error(t, err)
+	_, err = NewSitemapWithOptions(WithShardStrategy(ShardByHash("part", 2)))
+	assert.Error(t, err)
+}
+
+// TestFormatManifestShardOptions tests the format, manifest and shard strategy options of SitemapIndex
+func TestFormatManifestShardOptions(t *testing.T) {
+	storage := NewMemoryStorage()
+	smi, err := NewSitemapIndexWithOptions(
+		WithHostname(baseURL),
+		WithStorage(storage),
+		WithCompress(false),
+		WithFormat(FormatText),
+		WithManifestName("manifest"),
+		WithShardStrategy(ShardByPathPrefix(map[string]string{"/blog/": "blog"}, "pages")),
+	)
+	if err != nil {
+		t.Fatal("Unable to build SitemapIndex:", err)
+	}
+	assert.NoError(t, smi.AddURL(&SitemapLoc{Loc: "/blog/post"}))
+	assert.NoError(t, smi.AddURL(&SitemapLoc{Loc: "/about"}))
+	_, err = smi.Save()
+	if err != nil {
+		t.Fatal("Unable to Save SitemapIndex:", err)
+	}
+	assert.ElementsMatch(t, []string{"sitemap.xml", "blog.txt", "pages.txt", "manifest.json"}, storage.Filenames())
+	assert.NotNil(t, smi.Manifest())
+
+	content, err := storage.ReadFile("blog.txt")
+	if err != nil {
+		t.Fatal("Unable to read sitemap:", err)
+	}
+	assert.Equal(t, baseURL+"/blog/post\n", string(content))
+	index, err := storage.ReadFile("sitemap.xml")
+	if err != nil {
+		t.Fatal("Unable to read sitemap index:", err)
+	}
+	assert.Contains(t, string(index), "<loc>"+baseURL+"/blog.txt</loc>")
+
+	sm, err := NewSitemapWithOptions(WithFormat(FormatText))
+	if err != nil {
+		t.Fatal("Unable to build Sitemap:", err)
+	}
+	assert.Equal(t, FormatText, sm.format)
+}
+
+// TestXSLStylesheetOptions tests the XSL stylesheet options of Sitemap and SitemapIndex
+func TestXSLStylesheetOptions(t *testing.T) {
+	sm, err := NewSitemapWithOptions(WithHostname(baseURL), WithXSLStylesheet("/style.xsl"))
+	if err != nil {
+		t.Fatal("Unable to build Sitemap:", err)
+	}
+	assert.NoError(t, sm.Add(&SitemapLoc{Loc: "/a"}))
+	sm.Finalize()
+	buf := bytes.Buffer{}
+	_, err = sm.WriteTo(&buf)
+	if err != nil {
+		t.Fatal("Unable to write Sitemap:", err)
+	}
+	assert.Contains(t, buf.String(), `<?xml-stylesheet type="text/xsl" href="/style.xsl"?>`)
+
+	storage := NewMemoryStorage()
+	smi, err := NewSitemapIndexWithOptions(
+		WithHostname(baseURL),
+		WithStorage(storage),
+		WithCompress(false),
+		WithDefaultXSLStylesheets(),
+	)
+	if err != nil {
+		t.Fatal("Unable to build SitemapIndex:", err)
+	}
+	assert.NoError(t, smi.NewSitemap().Add(&SitemapLoc{Loc: "/a"}))
+	_, err = smi.Save()
+	if err != nil {
+		t.Fatal("Unable to Save SitemapIndex:", err)
+	}
+	assert.ElementsMatch(t, []string{"sitemap.xml", "sitemap1.xml", DefaultSitemapXSLName, DefaultSitemapIndexXSLName}, storage.Filenames())
+	for filename, href := range map[string]string{"sitemap.xml": DefaultSitemapIndexXSLName, "sitemap1.xml": DefaultSitemapXSLName} {
+		content, err := storage.ReadFile(filename)
+		if err != nil {
+			t.Fatal("Unable to read file:", err)
+		}
+		assert.Contains(t, string(content), `href="`+href+`"`, filename)
+	}
+}
+
+// TestSetMaxURLsCountPropagation tests that SetMaxURLsCount changes the limit of existing parts
+func TestSetMaxURLsCountPropagation(t *testing.T) {
+	sm, err := NewSitemapWithOptions(WithMaxURLsCount(1))
+	if err != nil {
+		t.Fatal("Unable to build Sitemap:", err)
+	}
+	for _, loc := range []string{"/a", "/b"} {
+		assert.NoError(t, sm.Add(&SitemapLoc{Loc: loc}))
+	}
+	sm.SetMaxURLsCount(2)
+	for _, loc := range []string{"/c", "/d"} {
+		assert.NoError(t, sm.Add(&SitemapLoc{Loc: loc}))
+	}
+	assert.Equal(t, 2, sm.NextSitemap.GetURLsCount())
+	assert.Equal(t, 1, sm.NextSitemap.NextSitemap.GetURLsCount())
+	assert.True(t, strings.HasSuffix(sm.NextSitemap.NextSitemap.content.String(), "/d</loc></url>"))
+}
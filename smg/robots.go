@@ -0,0 +1,97 @@
+package smg
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	robotsSitemapDirective string = "Sitemap:"
+	robotsBlockBegin       string = "# BEGIN sitemap-generator"
+	robotsBlockEnd         string = "# END sitemap-generator"
+)
+
+// UpdateRobotsTxt writes or updates the robots.txt file in filename path with
+// Sitemap directives pointing at the sitemapURLs. The directives are written in a
+// block between marker comments which is replaced as a whole by the next update,
+// so the directives of renamed or removed files do not remain. The block is placed
+// at the end of file in case of absence. Sitemap directives of the sitemapURLs
+// outside the block are removed, while the other lines including unrelated Sitemap
+// directives, user-agent groups and their rules are preserved as they are.
+// the file is created in case of absence.
+func UpdateRobotsTxt(filename string, sitemapURLs ...string) error {
+	content, err := os.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	urls := make(map[string]bool)
+	block := []string{robotsBlockBegin}
+	for _, sitemapURL := range sitemapURLs {
+		if !urls[sitemapURL] {
+			block = append(block, robotsSitemapDirective+" "+sitemapURL)
+			urls[sitemapURL] = true
+		}
+	}
+	block = append(block, robotsBlockEnd)
+
+	lines := make([]string, 0)
+	inBlock, replaced := false, false
+	if len(content) > 0 {
+		for _, line := range strings.Split(string(content), "\n") {
+			switch trimmed := strings.TrimSpace(line); {
+			case trimmed == robotsBlockBegin:
+				inBlock = true
+				if !replaced {
+					lines = append(lines, block...)
+					replaced = true
+				}
+			case trimmed == robotsBlockEnd:
+				inBlock = false
+			case inBlock:
+			default:
+				if sitemapURL, ok := sitemapDirective(line); ok && urls[sitemapURL] {
+					continue
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if !replaced {
+		// Sitemap directives are not part of any group, so they are placed at the
+		// end of file and separated from the last group by a blank line
+		if len(lines) > 0 {
+			if _, ok := sitemapDirective(lines[len(lines)-1]); !ok {
+				lines = append(lines, "")
+			}
+		}
+		lines = append(lines, block...)
+	}
+
+	buf := bytes.Buffer{}
+	for _, line := range lines {
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+
+	err = checkAndMakeDir(filepath.Dir(filename))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, buf.Bytes(), 0666)
+}
+
+// sitemapDirective returns the URL of the robots.txt line in case of being a Sitemap directive.
+func sitemapDirective(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if len(line) < len(robotsSitemapDirective) ||
+		!strings.EqualFold(line[:len(robotsSitemapDirective)], robotsSitemapDirective) {
+		return "", false
+	}
+	return strings.TrimSpace(line[len(robotsSitemapDirective):]), true
+}
@@ -0,0 +1,127 @@
+package smg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestUpdateRobotsTxt tests that the written Sitemap directives are replaced and the other rules are preserved
+func TestUpdateRobotsTxt(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "robots.txt")
+	existing := "User-agent: *\nDisallow: /admin/\nsitemap: https://www.example.com/old_sitemap.xml\n\nUser-agent: Googlebot\nAllow: /\n\n"
+	err := os.WriteFile(filename, []byte(existing), 0666)
+	if err != nil {
+		t.Fatal("Unable to write robots.txt:", err)
+	}
+	update := func(sitemapURLs ...string) string {
+		err := UpdateRobotsTxt(filename, sitemapURLs...)
+		if err != nil {
+			t.Fatal("Unable to update robots.txt:", err)
+		}
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal("Unable to read robots.txt:", err)
+		}
+		return string(content)
+	}
+
+	rules := "User-agent: *\nDisallow: /admin/\nsitemap: https://www.example.com/old_sitemap.xml\n\nUser-agent: Googlebot\nAllow: /\n\n"
+	assert.Equal(t, rules+"# BEGIN sitemap-generator\nSitemap: https://www.example.com/sitemap.xml\n# END sitemap-generator\n",
+		update(baseURL+"/sitemap.xml"))
+
+	// the block is replaced as a whole, so the directive of sitemap.xml does not remain
+	assert.Equal(t, rules+"# BEGIN sitemap-generator\nSitemap: https://www.example.com/news.xml\n# END sitemap-generator\n",
+		update(baseURL+"/news.xml", baseURL+"/news.xml"))
+
+	// the directives of the URLs outside the block are moved into it
+	rules = "User-agent: *\nDisallow: /admin/\n\nUser-agent: Googlebot\nAllow: /\n\n"
+	assert.Equal(t, rules+"# BEGIN sitemap-generator\nSitemap: https://www.example.com/old_sitemap.xml\n# END sitemap-generator\n",
+		update(baseURL+"/old_sitemap.xml"))
+
+	// the block is replaced in place
+	err = os.WriteFile(filename, []byte("# BEGIN sitemap-generator\nSitemap: https://www.example.com/a.xml\n"+
+		"# END sitemap-generator\n\nUser-agent: *\nDisallow: /admin/\n"), 0666)
+	if err != nil {
+		t.Fatal("Unable to write robots.txt:", err)
+	}
+	assert.Equal(t, "# BEGIN sitemap-generator\nSitemap: https://www.example.com/b.xml\n"+
+		"# END sitemap-generator\n\nUser-agent: *\nDisallow: /admin/\n", update(baseURL+"/b.xml"))
+}
+
+// TestSitemapIndexUpdateRobotsTxt tests the robots.txt creation using the public URL of SitemapIndex
+// and replacing the directive of the renamed SitemapIndex
+func TestSitemapIndexUpdateRobotsTxt(t *testing.T) {
+	path := t.TempDir()
+	filename := filepath.Join(path, "robots.txt")
+
+	smi := NewSitemapIndex(false)
+	smi.SetHostname(baseURL)
+	smi.SetOutputPath(filepath.Join(path, "sitemaps"))
+	smi.SetServerURI("/sitemaps/")
+	smi.SetSitemapIndexName("index")
+	err := smi.UpdateRobotsTxt(filename)
+	assert.Error(t, err)
+
+	err = smi.NewSitemap().Add(&SitemapLoc{Loc: "/test"})
+	if err != nil {
+		t.Fatal("Unable to add SitemapLoc:", err)
+	}
+	save := func() string {
+		_, err := smi.Save()
+		if err != nil {
+			t.Fatal("Unable to Save SitemapIndex:", err)
+		}
+		err = smi.UpdateRobotsTxt(filename)
+		if err != nil {
+			t.Fatal("Unable to update robots.txt:", err)
+		}
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal("Unable to read robots.txt:", err)
+		}
+		return string(content)
+	}
+	assert.Equal(t, "# BEGIN sitemap-generator\nSitemap: https://www.example.com/sitemaps/index.xml.gz\n"+
+		"# END sitemap-generator\n", save())
+
+	smi.SetSitemapIndexName("main")
+	content := save()
+	assert.NotContains(t, content, "index.xml.gz")
+	assert.Equal(t, "# BEGIN sitemap-generator\nSitemap: https://www.example.com/sitemaps/main.xml.gz\n"+
+		"# END sitemap-generator\n", content)
+}
+
+// TestSitemapUpdateRobotsTxt tests the robots.txt directives of a split Sitemap
+func TestSitemapUpdateRobotsTxt(t *testing.T) {
+	path := t.TempDir()
+	filename := filepath.Join(path, "robots.txt")
+
+	sm := NewSitemap(false)
+	sm.SetHostname(baseURL)
+	sm.SetOutputPath(path)
+	sm.SetCompress(false)
+	sm.SetMaxURLsCount(1)
+	for _, route := range []string{"/a", "/b"} {
+		err := sm.Add(&SitemapLoc{Loc: route})
+		if err != nil {
+			t.Fatal("Unable to add SitemapLoc:", err)
+		}
+	}
+	_, err := sm.Save()
+	if err != nil {
+		t.Fatal("Unable to Save Sitemap:", err)
+	}
+	err = sm.UpdateRobotsTxt(filename)
+	if err != nil {
+		t.Fatal("Unable to update robots.txt:", err)
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal("Unable to read robots.txt:", err)
+	}
+	assert.Equal(t, "# BEGIN sitemap-generator\nSitemap: https://www.example.com/sitemap.xml\n"+
+		"Sitemap: https://www.example.com/sitemap-2.xml\n# END sitemap-generator\n", string(content))
+}
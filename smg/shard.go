@@ -0,0 +1,145 @@
+package smg
+
+import (
+	"hash/fnv"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ShardFunc returns the Name of the Sitemap of SitemapIndex which the URL is added into
+// by SitemapIndex.AddURL. Empty name adds the URL into a default Sitemap.
+type ShardFunc func(u *SitemapLoc) string
+
+// ShardByPathPrefix routes the URLs by the longest matching prefix of their path in
+// prefixes which maps the path prefixes to the Sitemap names. The other URLs are routed
+// to defaultName.
+func ShardByPathPrefix(prefixes map[string]string, defaultName string) ShardFunc {
+	return func(u *SitemapLoc) string {
+		p := locPath(u.Loc)
+		name, length := defaultName, -1
+		for prefix, prefixName := range prefixes {
+			if strings.HasPrefix(p, prefix) && len(prefix) > length {
+				name, length = prefixName, len(prefix)
+			}
+		}
+		return name
+	}
+}
+
+// ShardByLastMod routes the URLs into date buckets using the layout for formatting their
+// lastmod in UTC which is appended to the name, like "2006-01" for monthly sitemaps
+// named "name-2022-02". The URLs without lastmod are routed to name. Old buckets keep
+// the same content as long as their URLs are not modified.
+func ShardByLastMod(name, layout string) ShardFunc {
+	return func(u *SitemapLoc) string {
+		if u.LastMod == nil {
+			return name
+		}
+		return name + "-" + u.LastMod.UTC().Format(layout)
+	}
+}
+
+// ShardByHash routes the URLs into n Sitemaps named "name-0" to "name-(n-1)"
+// by the FNV-1a hash of their Loc which keeps each URL in the same Sitemap.
+func ShardByHash(name string, n int) ShardFunc {
+	return func(u *SitemapLoc) string {
+		if n <= 1 {
+			return name + "-0"
+		}
+		h := fnv.New32a()
+		h.Write([]byte(u.Loc))
+		return name + "-" + strconv.Itoa(int(h.Sum32()%uint32(n)))
+	}
+}
+
+// extensionTypes maps the path extensions to media types for ShardByContentType.
+// It is fixed instead of the mime database of the host, so the URLs are sharded
+// the same on every machine.
+var extensionTypes = map[string]string{
+	".html":  "text/html",
+	".htm":   "text/html",
+	".xhtml": "application/xhtml+xml",
+	".php":   "text/html",
+	".asp":   "text/html",
+	".aspx":  "text/html",
+	".jsp":   "text/html",
+	".txt":   "text/plain",
+	".csv":   "text/csv",
+	".xml":   "application/xml",
+	".json":  "application/json",
+	".pdf":   "application/pdf",
+	".rtf":   "application/rtf",
+	".doc":   "application/msword",
+	".docx":  "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xls":   "application/vnd.ms-excel",
+	".xlsx":  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".ppt":   "application/vnd.ms-powerpoint",
+	".pptx":  "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".odt":   "application/vnd.oasis.opendocument.text",
+	".ods":   "application/vnd.oasis.opendocument.spreadsheet",
+	".epub":  "application/epub+zip",
+	".zip":   "application/zip",
+	".gz":    "application/gzip",
+	".jpg":   "image/jpeg",
+	".jpeg":  "image/jpeg",
+	".png":   "image/png",
+	".gif":   "image/gif",
+	".webp":  "image/webp",
+	".avif":  "image/avif",
+	".svg":   "image/svg+xml",
+	".bmp":   "image/bmp",
+	".ico":   "image/vnd.microsoft.icon",
+	".tif":   "image/tiff",
+	".tiff":  "image/tiff",
+	".mp4":   "video/mp4",
+	".m4v":   "video/mp4",
+	".webm":  "video/webm",
+	".mov":   "video/quicktime",
+	".avi":   "video/x-msvideo",
+	".mkv":   "video/x-matroska",
+	".mpeg":  "video/mpeg",
+	".mp3":   "audio/mpeg",
+	".m4a":   "audio/mp4",
+	".wav":   "audio/wav",
+	".ogg":   "audio/ogg",
+	".flac":  "audio/flac",
+}
+
+// ShardByContentType routes the URLs by the media type of their path extension which
+// is "text/html" for the paths without extension and "application/octet-stream" for
+// unknown extensions. types maps the media types, or the top-level types like "image/*",
+// to the Sitemap names. The other URLs are routed to defaultName.
+func ShardByContentType(types map[string]string, defaultName string) ShardFunc {
+	return func(u *SitemapLoc) string {
+		mediaType := "text/html"
+		if ext := path.Ext(locPath(u.Loc)); ext != "" {
+			mediaType = "application/octet-stream"
+			if t, ok := extensionTypes[strings.ToLower(ext)]; ok {
+				mediaType = t
+			}
+		}
+		if name, ok := types[mediaType]; ok {
+			return name
+		}
+		if i := strings.IndexByte(mediaType, '/'); i >= 0 {
+			if name, ok := types[mediaType[:i]+"/*"]; ok {
+				return name
+			}
+		}
+		return defaultName
+	}
+}
+
+// locPath returns the path of loc which may be either absolute or relative.
+func locPath(loc string) string {
+	u, err := url.Parse(loc)
+	if err != nil {
+		return loc
+	}
+	if !strings.HasPrefix(u.Path, "/") {
+		return "/" + u.Path
+	}
+	return u.Path
+}
@@ -0,0 +1,96 @@
+package smg
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSitemapIndexShardByLastMod tests routing URLs into monthly Sitemaps which are built on demand
+func TestSitemapIndexShardByLastMod(t *testing.T) {
+	storage := NewMemoryStorage()
+	smi := NewSitemapIndex(false)
+	smi.SetHostname(baseURL)
+	smi.SetStorage(storage)
+	smi.SetCompress(false)
+	smi.SetShardStrategy(ShardByLastMod("posts", "2006-01"))
+
+	jan := time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC)
+	janLast := time.Date(2022, 1, 20, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+	for _, u := range []*SitemapLoc{
+		{Loc: "/a", LastMod: &janLast},
+		{Loc: "/b", LastMod: &feb},
+		{Loc: "/c", LastMod: &jan},
+		{Loc: "/d"},
+	} {
+		err := smi.AddURL(u)
+		if err != nil {
+			t.Fatal("Unable to add SitemapLoc:", err)
+		}
+	}
+	_, err := smi.Save()
+	if err != nil {
+		t.Fatal("Unable to Save SitemapIndex:", err)
+	}
+
+	assert.ElementsMatch(t, []string{"sitemap.xml", "posts-2022-01.xml", "posts-2022-02.xml", "posts.xml"}, storage.Filenames())
+	content, err := storage.ReadFile("posts-2022-01.xml")
+	if err != nil {
+		t.Fatal("Unable to read sitemap:", err)
+	}
+	assert.Equal(t, 2, strings.Count(string(content), "<url>"))
+
+	lastMods := make(map[string]*time.Time)
+	for _, loc := range smi.SitemapLocs {
+		lastMods[loc.Loc] = loc.LastMod
+	}
+	assert.Equal(t, janLast, *lastMods[baseURL+"/posts-2022-01.xml"])
+	assert.Equal(t, feb, *lastMods[baseURL+"/posts-2022-02.xml"])
+	// the Sitemap of URLs without lastmod does not have any lastmod
+	assert.Nil(t, lastMods[baseURL+"/posts.xml"])
+}
+
+// TestSitemapIndexShardStrategies tests the path prefix, hash and content type strategies
+func TestSitemapIndexShardStrategies(t *testing.T) {
+	byPrefix := ShardByPathPrefix(map[string]string{"/blog/": "blog", "/blog/news/": "news"}, "pages")
+	assert.Equal(t, "blog", byPrefix(&SitemapLoc{Loc: "https://www.example.com/blog/post"}))
+	assert.Equal(t, "news", byPrefix(&SitemapLoc{Loc: "blog/news/item"}))
+	assert.Equal(t, "pages", byPrefix(&SitemapLoc{Loc: "/about"}))
+
+	byHash := ShardByHash("part", 4)
+	name := byHash(&SitemapLoc{Loc: "/a"})
+	assert.True(t, strings.HasPrefix(name, "part-"))
+	assert.Equal(t, name, byHash(&SitemapLoc{Loc: "/a"}))
+
+	byType := ShardByContentType(map[string]string{"application/pdf": "documents", "image/*": "images"}, "pages")
+	assert.Equal(t, "documents", byType(&SitemapLoc{Loc: "/files/report.pdf?v=2"}))
+	assert.Equal(t, "images", byType(&SitemapLoc{Loc: "/logo.png"}))
+	assert.Equal(t, "pages", byType(&SitemapLoc{Loc: "/about"}))
+	assert.Equal(t, "images", byType(&SitemapLoc{Loc: "/photos/IMG_01.JPG"}))
+	assert.Equal(t, "pages", byType(&SitemapLoc{Loc: "/archive.unknown"}))
+
+	// existing Sitemaps are used by their Name and the other URLs are added into a default Sitemap
+	smi := NewSitemapIndex(false)
+	blog := smi.NewSitemap()
+	blog.SetName("blog")
+	smi.SetShardStrategy(byPrefix)
+	assert.NoError(t, smi.AddURL(&SitemapLoc{Loc: "/blog/post"}))
+	assert.NoError(t, smi.AddURL(&SitemapLoc{Loc: "/about"}))
+	assert.Equal(t, 1, blog.GetURLsCount())
+	assert.Len(t, smi.Sitemaps, 2)
+	assert.Equal(t, "pages", smi.Sitemaps[1].Name)
+
+	// explicit names bypass the shard strategy
+	smi.SetShardStrategy(byPrefix)
+	assert.NoError(t, smi.AddURLToShard("blog", &SitemapLoc{Loc: "/about-blog"}))
+	assert.NoError(t, smi.AddURLToShard("", &SitemapLoc{Loc: "/blog/other"}))
+	assert.Equal(t, 3, blog.GetURLsCount())
+	assert.Len(t, smi.Sitemaps, 2)
+
+	smi.SetShardStrategy(nil)
+	assert.NoError(t, smi.AddURL(&SitemapLoc{Loc: "/other"}))
+	assert.Len(t, smi.Sitemaps, 3)
+}
@@ -2,11 +2,11 @@ package smg
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/xml"
 	"fmt"
 	"io"
-	"net/url"
-	"path"
+	"strings"
 	"time"
 )
 
@@ -24,13 +24,30 @@ const (
 	Never   ChangeFreq = "never"
 )
 
+// Format is used for defining the output format of Sitemap files.
+type Format int
+
+// predefined Format values
+const (
+	// FormatXML is the default sitemaps.org XML format.
+	FormatXML Format = iota
+	// FormatText is the UTF-8 text format which contains one URL per line.
+	FormatText
+)
+
 const (
-	fileExt             string = ".xml"
-	fileGzExt           string = ".xml.gz"
-	maxFileSize         int    = 52428000 // decreased 800 byte to prevent a small bug to fail a big program :)
-	defaultMaxURLsCount int    = 50000
-	xmlUrlsetOpenTag    string = `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">`
-	xmlUrlsetCloseTag   string = "</urlset>\n"
+	fileExt           string = ".xml"
+	fileGzExt         string = ".xml.gz"
+	fileTxtExt        string = ".txt"
+	fileTxtGzExt      string = ".txt.gz"
+	xmlUrlsetOpenTag  string = `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">`
+	xmlUrlsetCloseTag string = "</urlset>\n"
+)
+
+// Limits of sitemaps.org protocol for each Sitemap file
+const (
+	MaxFileSize  int = 52428800 // 50MiB uncompressed
+	MaxURLsCount int = 50000
 )
 
 // Sitemap struct which contains Options for general attributes,
@@ -41,39 +58,125 @@ type Sitemap struct {
 	SitemapIndexLoc *SitemapIndexLoc
 	NextSitemap     *Sitemap
 	maxURLsCount    int
+	maxFileBytes    int
+	maxGzipBytes    int
+	gzipSize        *gzipSize
 	fileNum         int
 	urlsCount       int
 	content         bytes.Buffer
 	tempBuf         *bytes.Buffer
 	xmlEncoder      *xml.Encoder
 	isFinalized     bool
+	finalURLs       []string
+	minLastMod      *time.Time
+	maxLastMod      *time.Time
+	savedFiles      []*savedFile
+	lastModSet      bool
+	usedNamespaces  map[string]bool
 }
 
 // NewSitemap builds and returns a new Sitemap.
 func NewSitemap(prettyPrint bool) *Sitemap {
-	t := time.Now().UTC()
-
 	s := &Sitemap{
-		SitemapIndexLoc: &SitemapIndexLoc{
-			LastMod: &t,
-		},
+		SitemapIndexLoc: &SitemapIndexLoc{},
 	}
+	t := s.Now().UTC()
+	s.SitemapIndexLoc.LastMod = &t
 	s.Compress = true
-	s.prettyPrint = prettyPrint
 	s.content = bytes.Buffer{}
-	s.content.Write([]byte(xml.Header))
-	s.content.Write([]byte(xmlUrlsetOpenTag))
 	s.tempBuf = &bytes.Buffer{}
 	s.Name = "sitemap"
-	s.maxURLsCount = defaultMaxURLsCount
-	s.xmlEncoder = xml.NewEncoder(s.tempBuf)
+	s.maxURLsCount = MaxURLsCount
+	s.maxFileBytes = MaxFileSize
 	if prettyPrint {
-		s.content.Write([]byte{'\n'})
-		s.xmlEncoder.Indent("", "  ")
+		s.setIndent("  ")
+	} else {
+		s.setIndent("")
 	}
 	return s
 }
 
+// setIndent sets the indent of pretty printed xml which is disabled by empty indent.
+// It must be called before adding any URL.
+func (s *Sitemap) setIndent(indent string) {
+	s.prettyPrint = indent != ""
+	s.indent = indent
+	s.xmlEncoder = xml.NewEncoder(s.tempBuf)
+	if s.prettyPrint {
+		s.xmlEncoder.Indent("", indent)
+	}
+}
+
+// urlsetOpenTag returns the urlset open tag which declares the namespaces
+// of the Extensions which are used in this part of Sitemap.
+func (s *Sitemap) urlsetOpenTag() string {
+	if len(s.usedNamespaces) == 0 {
+		return xmlUrlsetOpenTag
+	}
+	return strings.TrimSuffix(xmlUrlsetOpenTag, ">") + s.namespaceDeclarations(s.usedNamespaces) + ">"
+}
+
+// newNamespaceDeclarations returns the xmlns attributes of the prefixes which are not used in this part yet.
+func (s *Sitemap) newNamespaceDeclarations(prefixes map[string]bool) string {
+	if len(prefixes) == 0 {
+		return ""
+	}
+	unused := make(map[string]bool)
+	for prefix := range prefixes {
+		if !s.usedNamespaces[prefix] {
+			unused[prefix] = true
+		}
+	}
+	return s.namespaceDeclarations(unused)
+}
+
+// header returns the beginning of the Sitemap file which contains
+// the xml header, the xml-stylesheet instruction and the urlset open tag.
+// Text format does not have any header.
+func (s *Sitemap) header() []byte {
+	if s.format == FormatText {
+		return nil
+	}
+	header := xml.Header + s.xslTag + s.urlsetOpenTag()
+	if s.prettyPrint {
+		header += "\n"
+	}
+	return []byte(header)
+}
+
+// headerLen returns the length of Sitemap header without building it.
+func (s *Sitemap) headerLen() int {
+	if s.format == FormatText {
+		return 0
+	}
+	n := len(xml.Header) + len(s.xslTag) + len(s.urlsetOpenTag())
+	if s.prettyPrint {
+		n++
+	}
+	return n
+}
+
+// footer returns the end of the Sitemap file which is appended by Finalize.
+// Text format does not have any footer.
+func (s *Sitemap) footer() []byte {
+	if s.format == FormatText {
+		return nil
+	}
+	if s.prettyPrint {
+		return []byte("\n" + xmlUrlsetCloseTag)
+	}
+	return []byte(xmlUrlsetCloseTag)
+}
+
+// fileSize returns the exact uncompressed size of the Sitemap file including it's header and footer.
+func (s *Sitemap) fileSize() int {
+	n := s.headerLen() + s.content.Len()
+	if !s.isFinalized {
+		n += len(s.footer())
+	}
+	return n
+}
+
 // Add adds an URL to a Sitemap.
 // in case of exceeding the Sitemaps.org limits, splits the Sitemap
 // into several Sitemap instances using a Linked List
@@ -81,79 +184,169 @@ func (s *Sitemap) Add(u *SitemapLoc) error {
 	if s.isFinalized {
 		return fmt.Errorf("sitemap is finalized")
 	}
-	return s.realAdd(u, 0, nil)
+	err := s.checkMaxURLsCount()
+	if err != nil {
+		return err
+	}
+	return s.realAdd(u, 0, nil, nil)
 }
 
-func (s *Sitemap) realAdd(u *SitemapLoc, locN int, locBytes []byte) error {
+func (s *Sitemap) realAdd(u *SitemapLoc, locN int, locBytes []byte, prefixes map[string]bool) error {
 	if s.NextSitemap != nil {
-		s.NextSitemap.realAdd(u, locN, locBytes)
-		return nil
+		return s.NextSitemap.realAdd(u, locN, locBytes, prefixes)
 	}
 
 	if s.urlsCount >= s.maxURLsCount {
 		s.buildNextSitemap()
-		return s.NextSitemap.realAdd(u, locN, locBytes)
+		return s.NextSitemap.realAdd(u, locN, locBytes, prefixes)
 	}
 
-	if len(u.Images) > 0 {
+	if locBytes == nil {
+		var err error
+		u.Loc, err = resolveLoc(s.Hostname, u.Loc)
+		if err != nil {
+			return err
+		}
 		for _, image := range u.Images {
-			output, err := url.Parse(s.Hostname)
+			image.ImageLoc, err = resolveImageLoc(s.Hostname, image.ImageLoc)
+			if err != nil {
+				return err
+			}
+		}
+		if s.format == FormatText {
+			locN, locBytes = s.encodeToText(u)
+		} else {
+			locN, locBytes, prefixes, err = s.encodeToXML(u)
 			if err != nil {
 				return err
 			}
-			output.Path = path.Join(output.Path, image.ImageLoc)
-			image.ImageLoc = output.String()
 		}
 	}
 
-	if locBytes == nil {
-		output, err := url.Parse(s.Hostname)
-		if err != nil {
-			return err
-		}
-		loc, err := url.Parse(u.Loc)
-		if err != nil {
-			return err
-		}
-		u.Loc = output.ResolveReference(loc).String()
-		locN, locBytes, err = s.encodeToXML(u)
-		if err != nil {
-			return err
+	declarations := s.newNamespaceDeclarations(prefixes)
+	if s.fileSize()+len(declarations)+locN > s.maxFileBytes {
+		if s.urlsCount == 0 {
+			return fmt.Errorf("URL %s needs %d bytes which exceeds the max file size %d", u.Loc, s.fileSize()+len(declarations)+locN, s.maxFileBytes)
 		}
+		s.buildNextSitemap()
+		return s.NextSitemap.realAdd(u, locN, locBytes, prefixes)
 	}
 
-	if locN+s.content.Len() >= maxFileSize {
-		s.buildNextSitemap()
-		return s.NextSitemap.realAdd(u, locN, locBytes)
+	if s.maxGzipBytes > 0 {
+		if s.gzipSize == nil {
+			s.gzipSize = newGzipSize(s.compressionLevel(true), s.header(), s.content.Bytes())
+		}
+		if !s.gzipSize.fits(len(declarations)+locN, len(s.footer()), s.maxGzipBytes) {
+			if s.urlsCount == 0 {
+				return fmt.Errorf("URL %s exceeds the max compressed file size %d", u.Loc, s.maxGzipBytes)
+			}
+			s.buildNextSitemap()
+			return s.NextSitemap.realAdd(u, locN, locBytes, prefixes)
+		}
+		s.gzipSize.write([]byte(declarations))
+		s.gzipSize.write(locBytes)
 	}
 
 	_, err := s.content.Write(locBytes)
 	if err != nil {
 		return err
 	}
+	for prefix := range prefixes {
+		if s.usedNamespaces == nil {
+			s.usedNamespaces = make(map[string]bool)
+		}
+		s.usedNamespaces[prefix] = true
+	}
 	s.urlsCount++
+	if u.LastMod != nil {
+		lastMod := *u.LastMod
+		if s.minLastMod == nil || lastMod.Before(*s.minLastMod) {
+			s.minLastMod = &lastMod
+		}
+		if s.maxLastMod == nil || lastMod.After(*s.maxLastMod) {
+			s.maxLastMod = &lastMod
+		}
+	}
 	return nil
 }
 
 // buildNextSitemap builds a new Sitemap instance based on current one
 // and connects to it via NextSitemap.
 func (s *Sitemap) buildNextSitemap() {
-	s.NextSitemap = NewSitemap(s.prettyPrint)
+	s.NextSitemap = NewSitemap(false)
+	s.NextSitemap.setIndent(s.indent)
+	s.NextSitemap.namespaces = s.namespaces
 	s.NextSitemap.Compress = s.Compress
 	s.NextSitemap.Name = s.Name
 	s.NextSitemap.Hostname = s.Hostname
 	s.NextSitemap.OutputPath = s.OutputPath
+	s.NextSitemap.ServerURI = s.ServerURI
 	s.NextSitemap.maxURLsCount = s.maxURLsCount
+	s.NextSitemap.maxFileBytes = s.maxFileBytes
+	s.NextSitemap.maxGzipBytes = s.maxGzipBytes
+	s.NextSitemap.lastModPrecision = s.lastModPrecision
+	s.NextSitemap.lastModLocation = s.lastModLocation
+	s.NextSitemap.xslTag = s.xslTag
+	s.NextSitemap.format = s.format
+	s.NextSitemap.storage = s.storage
+	s.NextSitemap.filenameTemplate = s.filenameTemplate
+	s.NextSitemap.hashFilenames = s.hashFilenames
+	s.NextSitemap.gzipLevel = s.gzipLevel
+	s.NextSitemap.bothVariants = s.bothVariants
 	s.NextSitemap.fileNum = s.fileNum + 1
+	if s.now != nil {
+		s.NextSitemap.SetClock(s.now)
+	}
+	s.NextSitemap.log = s.log
+	s.logger().Debug("sitemap is split", "name", s.Name, "part", s.fileNum+2, "urls", s.urlsCount)
+	s.gzipSize = nil
 }
 
-func (s *Sitemap) encodeToXML(loc *SitemapLoc) (int, []byte, error) {
-	err := s.xmlEncoder.Encode(loc)
+// encodeToXML encodes the loc as an <url> element and returns the prefixes of namespaces
+// which are used by it's Extensions.
+func (s *Sitemap) encodeToXML(loc *SitemapLoc) (int, []byte, map[string]bool, error) {
+	extensions, prefixes, err := s.encodeExtensions(loc.Extensions)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	err = s.xmlEncoder.Encode(&xmlSitemapLoc{
+		Loc:        loc.Loc,
+		LastMod:    s.formatLastMod(loc.LastMod),
+		ChangeFreq: loc.ChangeFreq,
+		Priority:   loc.Priority,
+		Images:     loc.Images,
+		Extensions: extensions,
+	})
 	if err != nil {
-		return 0, nil, err
+		s.tempBuf.Reset()
+		return 0, nil, nil, err
 	}
 	defer s.tempBuf.Reset()
-	return s.tempBuf.Len(), s.tempBuf.Bytes(), nil
+	return s.tempBuf.Len(), s.tempBuf.Bytes(), prefixes, nil
+}
+
+// encodeToText encodes the loc as a line of text format.
+func (s *Sitemap) encodeToText(loc *SitemapLoc) (int, []byte) {
+	line := []byte(loc.Loc + "\n")
+	return len(line), line
+}
+
+// RegisterNamespace registers an XML namespace using the prefix for the Extensions of SitemapLoc.
+// The prefix must be a valid XML name which is not reserved and the uri must be an absolute URI.
+// The namespace is declared only on the urlset tag of the Sitemap files whose URLs use it.
+// Registering the same namespace again does nothing.
+func (s *Sitemap) RegisterNamespace(prefix, uri string) error {
+	registered, err := checkNamespace(s.namespaces, prefix, uri)
+	if err != nil {
+		return err
+	}
+	if !registered {
+		s.namespaces = append(s.namespaces[:len(s.namespaces):len(s.namespaces)], xmlNamespace{prefix: prefix, uri: uri})
+	}
+	if s.NextSitemap != nil {
+		return s.NextSitemap.RegisterNamespace(prefix, uri)
+	}
+	return nil
 }
 
 // SetName sets the Name of Sitemap output xml file
@@ -187,14 +380,109 @@ func (s *Sitemap) SetOutputPath(outputPath string) {
 	}
 }
 
-// SetLastMod sets the LastMod if this Sitemap which will be used in it's URL in SitemapIndex
+// SetStorage sets the Storage which is used by Save method for writing the files.
+// Default is the local file system. MemoryStorage keeps the files in memory.
+// Note: you do not have to call SetStorage in case you are building Sitemap using SitemapIndex.NewSitemap.
+func (s *Sitemap) SetStorage(storage Storage) {
+	s.storage = storage
+	s.passClock()
+	if s.NextSitemap != nil {
+		s.NextSitemap.SetStorage(storage)
+	}
+}
+
+// SetServerURI sets the ServerURI of Sitemap which is the path of saved files on the server.
+// it is used for making the public URLs of Sitemap files, e.g. in robots.txt.
+// Note: you do not have to call SetServerURI in case you are building Sitemap using SitemapIndex.NewSitemap.
+func (s *Sitemap) SetServerURI(serverURI string) {
+	s.ServerURI = serverURI
+	if s.NextSitemap != nil {
+		s.NextSitemap.SetServerURI(serverURI)
+	}
+}
+
+// SetLastMod sets the LastMod if this Sitemap which will be used in it's URL in SitemapIndex.
+// The URL of Sitemap in SitemapIndex does not have any lastmod unless it is set.
 func (s *Sitemap) SetLastMod(lastMod *time.Time) {
 	s.SitemapIndexLoc.LastMod = lastMod
+	s.lastModSet = true
 	if s.NextSitemap != nil {
 		s.NextSitemap.SetLastMod(lastMod)
 	}
 }
 
+// SetClock sets the clock which is used instead of time.Now, e.g. FixedClock for reproducible builds.
+// The LastMod of Sitemap in SitemapIndex is reset to the time of clock unless it is set by SetLastMod.
+// The clock is passed on to the Storage which has a clock like MemoryStorage.
+// nil sets the default clock which is time.Now.
+func (s *Sitemap) SetClock(now func() time.Time) {
+	s.now = now
+	s.passClock()
+	if !s.lastModSet {
+		t := s.Now().UTC()
+		s.SitemapIndexLoc.LastMod = &t
+	}
+	if s.NextSitemap != nil {
+		s.NextSitemap.SetClock(now)
+	}
+}
+
+// SetLogger sets the Logger of Sitemap which is silent by default.
+// Note: you do not have to call SetLogger in case you are building Sitemap using SitemapIndex.NewSitemap.
+func (s *Sitemap) SetLogger(logger Logger) {
+	s.log = logger
+	if s.NextSitemap != nil {
+		s.NextSitemap.SetLogger(logger)
+	}
+}
+
+// SetLastModPrecision sets the W3C Datetime precision of lastmod values in Sitemap.
+// Default is PrecisionDefault which keeps the nanoseconds.
+func (s *Sitemap) SetLastModPrecision(precision TimePrecision) {
+	s.lastModPrecision = precision
+	if s.NextSitemap != nil {
+		s.NextSitemap.SetLastModPrecision(precision)
+	}
+}
+
+// SetLastModLocation sets the timezone which lastmod values are converted to
+// before formatting. nil keeps the original timezone of each value.
+func (s *Sitemap) SetLastModLocation(loc *time.Location) {
+	s.lastModLocation = loc
+	if s.NextSitemap != nil {
+		s.NextSitemap.SetLastModLocation(loc)
+	}
+}
+
+// SetXSLStylesheet sets the href of an XSL stylesheet which is emitted as an
+// xml-stylesheet processing instruction after the xml header of Sitemap.
+// it makes the Sitemap human-readable in browsers. empty href removes it.
+func (s *Sitemap) SetXSLStylesheet(href string) {
+	s.xslTag = xslStylesheetTag(href)
+	if s.NextSitemap != nil {
+		s.NextSitemap.SetXSLStylesheet(href)
+	}
+}
+
+// SetDefaultXSLStylesheet enables the embedded default XSL stylesheet for Sitemap.
+// The stylesheet is saved alongside the Sitemap files by Save method using
+// DefaultSitemapXSLName and is referenced by a relative href.
+func (s *Sitemap) SetDefaultXSLStylesheet() {
+	s.SetXSLStylesheet(DefaultSitemapXSLName)
+	s.saveDefaultXSL = true
+}
+
+// SetFormat sets the output Format of Sitemap. Default is FormatXML.
+// When FormatText is set, the output file contains one URL per line with
+// .txt extension and all other properties of SitemapLoc are ignored.
+// It must be called before adding any URL.
+func (s *Sitemap) SetFormat(format Format) {
+	s.format = format
+	if s.NextSitemap != nil {
+		s.NextSitemap.SetFormat(format)
+	}
+}
+
 // SetCompress sets the Compress option to be either enabled or disabled for Sitemap
 // When Compress is enabled, the output file is compressed using gzip with .xml.gz extension.
 func (s *Sitemap) SetCompress(compress bool) {
@@ -204,9 +492,117 @@ func (s *Sitemap) SetCompress(compress bool) {
 	}
 }
 
-// SetMaxURLsCount sets the maximum # of URLs for a sitemap
+// SetFilenameTemplate sets the template of filenames of Sitemap files without extension
+// which is appended by Save method. The placeholders are {name} for Name, {part} for the
+// 1-based part number which is zero-padded to the digits of the number of parts or to N
+// digits using {part:N}, {date} for the date of LastMod in UTC and {hash} for the first
+// 12, or N using {hash:N}, hex digits of SHA-256 of the uncompressed file content.
+// e.g. "{name}-{part:3}" saves "sitemap-001.xml", "sitemap-002.xml", etc.
+// Empty template keeps the default naming: Name, Name1, Name2, etc.
+func (s *Sitemap) SetFilenameTemplate(template string) error {
+	t, err := parseFilenameTemplate(template)
+	if err != nil {
+		return err
+	}
+	s.setFilenameTemplate(t)
+	return nil
+}
+
+func (s *Sitemap) setFilenameTemplate(t filenameTemplate) {
+	s.filenameTemplate = t
+	if s.NextSitemap != nil {
+		s.NextSitemap.setFilenameTemplate(t)
+	}
+}
+
+// SetHashFilenames enables appending a hash of the content of each part to it's filename,
+// e.g. "sitemap-3f2a9c1b7d4e.xml.gz". The hash is the same as {hash} of filename templates,
+// so unchanged parts keep their filenames and can be cached forever as immutable files.
+// It is ignored in case of a filename template which already contains {hash}.
+func (s *Sitemap) SetHashFilenames(enabled bool) {
+	s.hashFilenames = enabled
+	if s.NextSitemap != nil {
+		s.NextSitemap.SetHashFilenames(enabled)
+	}
+}
+
+// SetCompressionLevel sets the gzip level of compressed files of Sitemap which is one of
+// gzip.DefaultCompression, gzip.HuffmanOnly or from gzip.BestSpeed to gzip.BestCompression.
+// Default is gzip.DefaultCompression. Disable Compress for the uncompressed files.
+func (s *Sitemap) SetCompressionLevel(level int) error {
+	err := checkCompressionLevel(level)
+	if err != nil {
+		return err
+	}
+	s.setCompressionLevel(level)
+	return nil
+}
+
+func (s *Sitemap) setCompressionLevel(level int) {
+	s.gzipLevel = level
+	s.gzipSize = nil
+	if s.NextSitemap != nil {
+		s.NextSitemap.setCompressionLevel(level)
+	}
+}
+
+// SetWriteBothVariants enables writing both the uncompressed and the gzip compressed variants
+// of each file side by side, e.g. sitemap.xml and sitemap.xml.gz for the gzip_static module
+// of web servers. Compress designates the variant which is returned by Save method and is
+// referenced by SitemapIndex.
+func (s *Sitemap) SetWriteBothVariants(enabled bool) {
+	s.bothVariants = enabled
+	if s.NextSitemap != nil {
+		s.NextSitemap.SetWriteBothVariants(enabled)
+	}
+}
+
+// SetMaxURLsCount sets the maximum # of URLs for each part of a sitemap
+// which must be between 1 and 50,000 of sitemaps.org protocol.
+// Add and Save return an error in case of an invalid count.
 func (s *Sitemap) SetMaxURLsCount(maxURLsCount int) {
 	s.maxURLsCount = maxURLsCount
+	if s.NextSitemap != nil {
+		s.NextSitemap.SetMaxURLsCount(maxURLsCount)
+	}
+}
+
+// checkMaxURLsCount validates the maximum # of URLs which is set by SetMaxURLsCount.
+func (s *Sitemap) checkMaxURLsCount() error {
+	if s.maxURLsCount < 1 || s.maxURLsCount > MaxURLsCount {
+		return fmt.Errorf("max URLs count %d must be between 1 and %d", s.maxURLsCount, MaxURLsCount)
+	}
+	return nil
+}
+
+// SetMaxFileSize sets the maximum uncompressed size in bytes of each part of Sitemap
+// including it's header and footer which must not exceed the 50MiB of sitemaps.org protocol.
+// e.g. 10MB makes smaller parts which are crawled faster.
+func (s *Sitemap) SetMaxFileSize(size int) error {
+	if size <= s.headerLen()+len(s.footer()) || size > MaxFileSize {
+		return fmt.Errorf("max file size %d must be more than the sitemap header and at most %d bytes", size, MaxFileSize)
+	}
+	s.maxFileBytes = size
+	if s.NextSitemap != nil {
+		return s.NextSitemap.SetMaxFileSize(size)
+	}
+	return nil
+}
+
+// SetMaxCompressedSize sets the maximum gzip compressed size in bytes of each part of Sitemap
+// in addition to the uncompressed size and URLs count limits. The compressed size is tracked
+// incrementally while adding the URLs and it is an upper bound of the size of saved files.
+// Zero disables it which is the default.
+func (s *Sitemap) SetMaxCompressedSize(size int) error {
+	if size < 0 {
+		return fmt.Errorf("max compressed file size %d must not be negative", size)
+	}
+	s.maxGzipBytes = size
+	s.gzipSize = nil
+	if s.NextSitemap != nil {
+		return s.NextSitemap.SetMaxCompressedSize(size)
+	}
+	return nil
 }
 
 // GetURLsCount returns the number of added URL items into this single sitemap.
@@ -216,54 +612,228 @@ func (s *Sitemap) GetURLsCount() int {
 
 // Finalize closes the XML data set and do not allow any further sm.Add() calls
 func (s *Sitemap) Finalize() {
-	if s.prettyPrint {
-		s.content.Write([]byte{'\n'})
-	}
-	s.content.Write([]byte(xmlUrlsetCloseTag))
-
+	s.content.Write(s.footer())
 	s.isFinalized = true
 }
 
-// Save makes the OutputPath in case of absence and saves the Sitemap into OutputPath using it's Name.
-// it returns the filename.
+// Save makes the OutputPath in case of absence and saves the Sitemap into OutputPath using it's Name
+// or filename template. it returns the filenames in ascending order of parts.
 func (s *Sitemap) Save() (filenames []string, err error) {
-	err = checkAndMakeDir(s.OutputPath)
+	err = s.checkMaxURLsCount()
 	if err != nil {
-		return
+		return nil, err
 	}
-
-	// Appends the fileNum at the end of filename in case of more than 0 (it is extended Sitemap)
-	var filename string
-	if s.fileNum > 0 {
-		filename = fmt.Sprintf("%s%d", s.Name, s.fileNum)
-	} else {
-		filename = s.Name
+	names, err := s.partFilenames()
+	if err != nil {
+		return nil, err
 	}
-
-	if s.Compress {
-		filename += fileGzExt
-	} else {
-		filename += fileExt
+	if name, ok := duplicateFilename(names); ok {
+		return nil, fmt.Errorf("sitemap %s has more than one part named %s", s.Name, name)
 	}
+	return s.saveParts(names)
+}
 
-	if !s.isFinalized {
-		s.Finalize()
-	}
+// saveParts saves the Sitemap and it's NextSitemaps using the filenames of partFilenames.
+// it returns the filenames in ascending order of parts.
+func (s *Sitemap) saveParts(names []string) (filenames []string, err error) {
+	filename := names[0]
 
-	_, err = writeToFile(filename, s.OutputPath, s.Compress, s.content.Bytes())
+	s.savedFiles, err = s.writeFile(filename, s.header(), s.content.Bytes())
 	if err != nil {
 		return
 	}
+	s.logger().Debug("sitemap is saved", "filename", filename, "urls", s.urlsCount)
+
+	if s.saveDefaultXSL && s.fileNum == 0 && s.format == FormatXML {
+		_, err = writeToFile(s.fileStorage(), DefaultSitemapXSLName, s.OutputPath, gzip.NoCompression, defaultSitemapXSL)
+		if err != nil {
+			return
+		}
+	}
 
+	filenames = []string{filename}
 	if s.NextSitemap != nil {
-		filenames, err = s.NextSitemap.Save()
+		nextFilenames, err := s.NextSitemap.saveParts(names[1:])
 		if err != nil {
 			return nil, err
 		}
+		filenames = append(filenames, nextFilenames...)
 	}
-	return append(filenames, filename), nil
+
+	s.finalURLs = make([]string, len(filenames))
+	for i, filename := range filenames {
+		s.finalURLs[i], err = publicURL(s.Hostname, s.ServerURI, filename)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return filenames, nil
+}
+
+// partFilenames finalizes the Sitemap and it's NextSitemaps and returns their filenames in order.
+func (s *Sitemap) partFilenames() ([]string, error) {
+	partCount := 0
+	for sm := s; sm != nil; sm = sm.NextSitemap {
+		if !sm.isFinalized {
+			sm.Finalize()
+		}
+		partCount++
+	}
+
+	date := s.Now()
+	if s.SitemapIndexLoc != nil && s.SitemapIndexLoc.LastMod != nil {
+		date = *s.SitemapIndexLoc.LastMod
+	}
+	filenames := make([]string, 0, partCount)
+	for sm := s; sm != nil; sm = sm.NextSitemap {
+		var filename string
+		switch {
+		case sm.filenameTemplate != "":
+			filename = sm.filenameTemplate.execute(&filenameData{
+				name:      sm.Name,
+				part:      len(filenames) + 1,
+				partCount: partCount,
+				date:      date,
+				content:   [][]byte{sm.header(), sm.content.Bytes()},
+			})
+		case sm.fileNum > 0:
+			// Appends the part number after a "-" in case of the extended Sitemaps which makes their
+			// filenames distinct from the default "sitemap%d" names of Sitemaps in SitemapIndex
+			filename = fmt.Sprintf("%s-%d", sm.Name, sm.fileNum+1)
+		default:
+			filename = sm.Name
+		}
+		if sm.hashFilenames && !sm.filenameTemplate.hasHash() {
+			filename += "-" + contentHash([][]byte{sm.header(), sm.content.Bytes()}, 0)
+		}
+		if filename == "" {
+			return nil, fmt.Errorf("empty filename of sitemap %s", s.Name)
+		}
+		filenames = append(filenames, filename+sm.fileExtension())
+	}
+	return filenames, nil
+}
+
+// FinalURLs returns the public URLs of the saved Sitemap files
+// which are made of Hostname, ServerURI and filenames.
+// it is empty before calling the Save method.
+func (s *Sitemap) FinalURLs() []string {
+	return s.finalURLs
+}
+
+// UpdateRobotsTxt writes or updates the robots.txt file in filename path
+// with Sitemap directives pointing at the public URLs of Sitemap files.
+// The Save method must be called before. See UpdateRobotsTxt function.
+func (s *Sitemap) UpdateRobotsTxt(filename string) error {
+	if len(s.finalURLs) == 0 {
+		return fmt.Errorf("the save method must be called before updating robots.txt")
+	}
+	return UpdateRobotsTxt(filename, s.finalURLs...)
+}
+
+// decodeLocs decodes the added URLs of Sitemap and it's NextSitemaps
+// and calls fn for each of them in order.
+func (s *Sitemap) decodeLocs(fn func(u *SitemapLoc) error) error {
+	for sm := s; sm != nil; sm = sm.NextSitemap {
+		var err error
+		if sm.format == FormatText {
+			err = sm.decodeText(fn)
+		} else {
+			err = sm.decodeXML(fn)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Sitemap) decodeText(fn func(u *SitemapLoc) error) error {
+	for _, line := range strings.Split(s.content.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		err := fn(&SitemapLoc{Loc: line})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
+func (s *Sitemap) decodeXML(fn func(u *SitemapLoc) error) error {
+	readers := []io.Reader{bytes.NewReader(s.header()), bytes.NewReader(s.content.Bytes())}
+	if !s.isFinalized {
+		readers = append(readers, strings.NewReader(xmlUrlsetCloseTag))
+	}
+	decoder := xml.NewDecoder(io.MultiReader(readers...))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "url" {
+			continue
+		}
+		var loc struct {
+			Loc        string     `xml:"loc"`
+			LastMod    string     `xml:"lastmod"`
+			ChangeFreq ChangeFreq `xml:"changefreq"`
+			Priority   float32    `xml:"priority"`
+			Images     []struct {
+				Loc string `xml:"loc"`
+			} `xml:"image"`
+		}
+		err = decoder.DecodeElement(&loc, &start)
+		if err != nil {
+			return err
+		}
+		lastMod, err := parseLastMod(loc.LastMod)
+		if err != nil {
+			return err
+		}
+		images := make([]*SitemapImage, len(loc.Images))
+		for i, image := range loc.Images {
+			images[i] = &SitemapImage{ImageLoc: image.Loc}
+		}
+		err = fn(&SitemapLoc{
+			Loc:        loc.Loc,
+			LastMod:    lastMod,
+			ChangeFreq: loc.ChangeFreq,
+			Priority:   loc.Priority,
+			Images:     images,
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// fileExtension returns the file extension of Sitemap based on its Format and Compress.
+func (s *Sitemap) fileExtension() string {
+	switch {
+	case s.format == FormatText && s.Compress:
+		return fileTxtGzExt
+	case s.format == FormatText:
+		return fileTxtExt
+	case s.Compress:
+		return fileGzExt
+	default:
+		return fileExt
+	}
+}
+
+// WriteTo writes the Sitemap content including its header to given io.Writer.
+// Finalize must be called before to make the content closed.
 func (s *Sitemap) WriteTo(w io.Writer) (n int64, err error) {
-	return s.content.WriteTo(w)
+	headerCount, err := w.Write(s.header())
+	if err != nil {
+		return 0, err
+	}
+	bodyCount, err := w.Write(s.content.Bytes())
+	return int64(headerCount + bodyCount), err
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -131,6 +132,48 @@ func TestSitemapAdd(t *testing.T) {
 	assert.Equal(t, expectedImage2, actualImage2)
 }
 
+// TestSitemapImageLocHostnamePath tests that relative image locs are joined
+// to the path of Hostname and absolute ones are kept
+func TestSitemapImageLocHostnamePath(t *testing.T) {
+	sm := NewSitemap(false)
+	sm.SetHostname("https://x.com/blog")
+	err := sm.Add(&SitemapLoc{
+		Loc: "/post",
+		Images: []*SitemapImage{
+			{"img.png"},
+			{"/img-2.png"},
+			{"https://cdn.x.com/img-3.png"},
+			{"//cdn.x.com/img-4.png"},
+		},
+	})
+	if err != nil {
+		t.Fatal("Unable to add SitemapLoc:", err)
+	}
+	sm.Finalize()
+
+	buf := bytes.Buffer{}
+	_, err = sm.WriteTo(&buf)
+	if err != nil {
+		t.Fatal("Unable to write Sitemap:", err)
+	}
+	var urlSet UrlSet
+	err = xml.Unmarshal(buf.Bytes(), &urlSet)
+	if err != nil {
+		t.Fatal("Unable to unmarhsall sitemap byte array into xml: ", err)
+	}
+	assert.Equal(t, "https://x.com/post", urlSet.Urls[0].Loc)
+	var images []string
+	for _, image := range urlSet.Urls[0].Images {
+		images = append(images, image.ImageLoc)
+	}
+	assert.Equal(t, []string{
+		"https://x.com/blog/img.png",
+		"https://x.com/blog/img-2.png",
+		"https://cdn.x.com/img-3.png",
+		"https://cdn.x.com/img-4.png",
+	}, images)
+}
+
 func TestWriteTo(t *testing.T) {
 	path := t.TempDir()
 	now := time.Now().UTC()
@@ -172,3 +215,212 @@ func TestWriteTo(t *testing.T) {
 	actualUrl := urlSet.Urls[0].Loc
 	assert.Equal(t, expectedUrl, actualUrl)
 }
+
+// TestLastModPrecision tests that the lastmod values are formatted using the precision and timezone options
+func TestLastModPrecision(t *testing.T) {
+	lastMod := time.Date(2022, 2, 12, 16, 29, 46, 450130000, time.UTC)
+	tehran := time.FixedZone("Asia/Tehran", 3*60*60+30*60)
+
+	tests := []struct {
+		precision TimePrecision
+		location  *time.Location
+		expected  string
+	}{
+		{PrecisionDefault, nil, "2022-02-12T16:29:46.45013Z"},
+		{PrecisionDate, nil, "2022-02-12"},
+		{PrecisionMinutes, nil, "2022-02-12T16:29Z"},
+		{PrecisionSeconds, nil, "2022-02-12T16:29:46Z"},
+		{PrecisionSeconds, tehran, "2022-02-12T19:59:46+03:30"},
+		{PrecisionDate, time.FixedZone("", 10*60*60), "2022-02-13"},
+	}
+	for _, test := range tests {
+		sm := NewSitemap(false)
+		sm.SetHostname(baseURL)
+		sm.SetLastModPrecision(test.precision)
+		sm.SetLastModLocation(test.location)
+		err := sm.Add(&SitemapLoc{
+			Loc:     "/test",
+			LastMod: &lastMod,
+		})
+		if err != nil {
+			t.Fatal("Unable to add SitemapLoc:", err)
+		}
+		sm.Finalize()
+
+		buf := bytes.Buffer{}
+		_, err = sm.WriteTo(&buf)
+		if err != nil {
+			t.Fatal("Unable to write to buffer:", err)
+		}
+		var urlSet UrlSet
+		err = xml.Unmarshal(buf.Bytes(), &urlSet)
+		if err != nil {
+			t.Fatal("Unable to unmarhsall sitemap byte array into xml: ", err)
+		}
+		assert.Equal(t, test.expected, urlSet.Urls[0].LasMod)
+	}
+}
+
+// TestSitemapXSLStylesheet tests that the xml-stylesheet instruction is emitted and the default XSL file is saved
+func TestSitemapXSLStylesheet(t *testing.T) {
+	path := t.TempDir()
+
+	sm := NewSitemap(false)
+	sm.SetHostname(baseURL)
+	sm.SetOutputPath(path)
+	sm.SetCompress(false)
+	sm.SetDefaultXSLStylesheet()
+	err := sm.Add(&SitemapLoc{Loc: "/test"})
+	if err != nil {
+		t.Fatal("Unable to add SitemapLoc:", err)
+	}
+
+	filenames, err := sm.Save()
+	if err != nil {
+		t.Fatal("Unable to Save Sitemap:", err)
+	}
+	assert.Equal(t, []string{"sitemap.xml"}, filenames)
+	assertOutputFile(t, path, DefaultSitemapXSLName)
+
+	byteValue, err := os.ReadFile(filepath.Join(path, filenames[0]))
+	if err != nil {
+		t.Fatal("Unable to read file:", err)
+	}
+	expected := xml.Header + `<?xml-stylesheet type="text/xsl" href="sitemap.xsl"?>` + "\n" + xmlUrlsetOpenTag
+	assert.True(t, bytes.HasPrefix(byteValue, []byte(expected)))
+
+	var urlSet UrlSet
+	err = xml.Unmarshal(byteValue, &urlSet)
+	if err != nil {
+		t.Fatal("Unable to unmarhsall sitemap byte array into xml: ", err)
+	}
+	assert.Equal(t, baseURL+"/test", urlSet.Urls[0].Loc)
+}
+
+// TestTextFormatSitemap tests the text output format which must be split by the URLs limit as XML
+func TestTextFormatSitemap(t *testing.T) {
+	path := t.TempDir()
+
+	sm := NewSitemap(true)
+	sm.SetName("text_sitemap")
+	sm.SetHostname(baseURL)
+	sm.SetOutputPath(path)
+	sm.SetCompress(false)
+	sm.SetFormat(FormatText)
+	sm.SetMaxURLsCount(2)
+
+	for _, route := range []string{"/a", "b/c", "/d?e=f"} {
+		err := sm.Add(&SitemapLoc{Loc: route, ChangeFreq: Daily})
+		if err != nil {
+			t.Fatal("Unable to add SitemapLoc:", err)
+		}
+	}
+	filenames, err := sm.Save()
+	if err != nil {
+		t.Fatal("Unable to Save Sitemap:", err)
+	}
+	assert.ElementsMatch(t, []string{"text_sitemap.txt", "text_sitemap-2.txt"}, filenames)
+
+	content, err := os.ReadFile(filepath.Join(path, "text_sitemap.txt"))
+	if err != nil {
+		t.Fatal("Unable to read file:", err)
+	}
+	assert.Equal(t, baseURL+"/a\n"+baseURL+"/b/c\n", string(content))
+
+	content, err = os.ReadFile(filepath.Join(path, "text_sitemap-2.txt"))
+	if err != nil {
+		t.Fatal("Unable to read file:", err)
+	}
+	assert.Equal(t, baseURL+"/d?e=f\n", string(content))
+
+	sm.SetCompress(true)
+	filenames, err = sm.Save()
+	if err != nil {
+		t.Fatal("Unable to Save Compressed Sitemap:", err)
+	}
+	assert.ElementsMatch(t, []string{"text_sitemap.txt.gz", "text_sitemap-2.txt.gz"}, filenames)
+}
+
+// TestSitemapMaxFileSize tests that the parts are packed exactly up to the max file size including the closing tag
+func TestSitemapMaxFileSize(t *testing.T) {
+	storage := NewMemoryStorage()
+	sm := NewSitemap(false)
+	sm.SetHostname(baseURL)
+	sm.SetStorage(storage)
+	sm.SetCompress(false)
+
+	// the size of a part with a single URL is the limit
+	probe := NewSitemap(false)
+	probe.SetHostname(baseURL)
+	assert.NoError(t, probe.Add(&SitemapLoc{Loc: "/0"}))
+	limit := probe.fileSize()
+	assert.NoError(t, sm.SetMaxFileSize(limit))
+	for _, route := range []string{"/1", "/2", "/3"} {
+		assert.NoError(t, sm.Add(&SitemapLoc{Loc: route}))
+	}
+	filenames, err := sm.Save()
+	if err != nil {
+		t.Fatal("Unable to Save Sitemap:", err)
+	}
+	assert.Len(t, filenames, 3)
+	for _, filename := range filenames {
+		content, err := storage.ReadFile(filename)
+		if err != nil {
+			t.Fatal("Unable to read file:", err)
+		}
+		assert.Equal(t, limit, len(content), filename)
+	}
+
+	assert.Error(t, sm.SetMaxFileSize(MaxFileSize+1))
+	assert.Error(t, sm.SetMaxFileSize(10))
+
+	// an invalid max URLs count is reported by Add and Save
+	invalid := NewSitemap(false)
+	invalid.SetStorage(NewMemoryStorage())
+	for _, count := range []int{0, 50001} {
+		invalid.SetMaxURLsCount(count)
+		assert.Error(t, invalid.Add(&SitemapLoc{Loc: "/a"}))
+		_, err := invalid.Save()
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 0, invalid.GetURLsCount())
+
+	// a single URL which does not fit in an empty part
+	small := NewSitemap(false)
+	assert.NoError(t, small.SetMaxFileSize(small.headerLen()+len(small.footer())+10))
+	assert.Error(t, small.Add(&SitemapLoc{Loc: "/a-long-url"}))
+}
+
+// TestSitemapMaxCompressedSize tests splitting the parts by their compressed size
+func TestSitemapMaxCompressedSize(t *testing.T) {
+	storage := NewMemoryStorage()
+	sm := NewSitemap(false)
+	sm.SetHostname(baseURL)
+	sm.SetStorage(storage)
+	limit := 20000
+	assert.NoError(t, sm.SetMaxCompressedSize(limit))
+	assert.Error(t, sm.SetMaxCompressedSize(-1))
+
+	for _, route := range buildRoutes(2000, 100, 20) {
+		err := sm.Add(&SitemapLoc{Loc: route})
+		if err != nil {
+			t.Fatal("Unable to add SitemapLoc:", err)
+		}
+	}
+	filenames, err := sm.Save()
+	if err != nil {
+		t.Fatal("Unable to Save Sitemap:", err)
+	}
+	assert.Greater(t, len(filenames), 1)
+	for i, filename := range filenames {
+		content, err := storage.ReadFile(filename)
+		if err != nil {
+			t.Fatal("Unable to read file:", err)
+		}
+		assert.LessOrEqual(t, len(content), limit, filename)
+		if i < len(filenames)-1 {
+			// all parts except the last one are filled near the limit
+			assert.Greater(t, len(content), limit*9/10, filename)
+		}
+	}
+}
@@ -2,14 +2,15 @@ package smg
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
 	"path"
+	"path/filepath"
 	"sync"
 	"time"
 )
@@ -21,17 +22,28 @@ import (
 // which wll be used for all URLs in SitemapIndex and it's Sitemaps.
 // SitemapLocs is list of location structs of its Sitemaps.
 // Sitemaps contains all Sitemaps which is belong to this SitemapIndex.
-// ServerURI is used for making url of Sitemap in SitemapIndex.
+// ServerURI of Options is used for making url of SitemapIndex and it's Sitemaps.
 type SitemapIndex struct {
 	Options
-	XMLName     xml.Name           `xml:"sitemapindex"`
-	Xmlns       string             `xml:"xmlns,attr"`
-	SitemapLocs []*SitemapIndexLoc `xml:"sitemap"`
-	Sitemaps    []*Sitemap         `xml:"-"`
-	ServerURI   string             `xml:"-"`
-	finalURL    string
-	mutex       sync.Mutex
-	wg          sync.WaitGroup
+	XMLName       xml.Name           `xml:"sitemapindex"`
+	Xmlns         string             `xml:"xmlns,attr"`
+	SitemapLocs   []*SitemapIndexLoc `xml:"sitemap"`
+	Sitemaps      []*Sitemap         `xml:"-"`
+	finalURL      string
+	indexURL      string
+	shardFunc     ShardFunc
+	shards        map[string]*Sitemap
+	shardLastMods map[*Sitemap]*time.Time
+	manifestName  string
+	manifest      *Manifest
+	savedFiles    []*savedFile
+	xslFiles      []*savedFile
+	savedLocs     map[*SitemapIndexLoc]bool
+	maxURLsCount  int
+	maxFileBytes  int
+	maxGzipBytes  int
+	mutex         sync.Mutex
+	wg            sync.WaitGroup
 }
 
 var (
@@ -47,15 +59,20 @@ var (
 // is not changeable after initialization.
 func NewSitemapIndex(prettyPrint bool) *SitemapIndex {
 	s := &SitemapIndex{
-		Xmlns:       "http://www.sitemaps.org/schemas/sitemap/0.9",
-		SitemapLocs: make([]*SitemapIndexLoc, 0),
-		Sitemaps:    make([]*Sitemap, 0),
-		mutex:       sync.Mutex{},
-		wg:          sync.WaitGroup{},
+		Xmlns:         "http://www.sitemaps.org/schemas/sitemap/0.9",
+		SitemapLocs:   make([]*SitemapIndexLoc, 0),
+		Sitemaps:      make([]*Sitemap, 0),
+		shards:        make(map[string]*Sitemap),
+		shardLastMods: make(map[*Sitemap]*time.Time),
+		mutex:         sync.Mutex{},
+		wg:            sync.WaitGroup{},
 	}
 	s.Name = "sitemap"
 	s.Compress = true
 	s.prettyPrint = prettyPrint
+	if prettyPrint {
+		s.indent = "  "
+	}
 	return s
 }
 
@@ -66,6 +83,64 @@ func (s *SitemapIndex) Add(u *SitemapIndexLoc) {
 	s.mutex.Unlock()
 }
 
+// AddURL adds an URL into the Sitemap which is chosen by the shard strategy of SitemapIndex.
+// The Sitemaps are built using NewSitemap method on demand and are named by the shard
+// strategy unless a Sitemap with the same Name exists. The lastmod of built Sitemaps in
+// the index is the latest lastmod of their URLs which keeps it unchanged for unchanged URLs.
+// The URLs are added into a single default Sitemap in case of absence of shard strategy.
+// It is safe for concurrent use.
+func (s *SitemapIndex) AddURL(u *SitemapLoc) error {
+	return s.AddURLToShard("", u)
+}
+
+// AddURLToShard adds an URL into the Sitemap named name like AddURL does for the names of
+// the shard strategy. Empty name routes the URL by the shard strategy like AddURL.
+// It is safe for concurrent use.
+func (s *SitemapIndex) AddURLToShard(name string, u *SitemapLoc) error {
+	if name == "" && s.shardFunc != nil {
+		name = s.shardFunc(u)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	sm, ok := s.shards[name]
+	if !ok {
+		for _, sitemap := range s.Sitemaps {
+			if name != "" && sitemap.Name == name {
+				sm = sitemap
+			}
+		}
+		if sm == nil {
+			sm = s.NewSitemap()
+			if name != "" {
+				sm.SetName(name)
+			}
+			s.shardLastMods[sm] = nil
+		}
+		s.shards[name] = sm
+	}
+
+	err := sm.Add(u)
+	if err != nil {
+		return err
+	}
+	if lastMod, ok := s.shardLastMods[sm]; ok && u.LastMod != nil {
+		if lastMod == nil || u.LastMod.After(*lastMod) {
+			lastMod = new(time.Time)
+			*lastMod = *u.LastMod
+			s.shardLastMods[sm] = lastMod
+			sm.SetLastMod(lastMod)
+		}
+	}
+	return nil
+}
+
+// SetShardStrategy sets the ShardFunc which routes the URLs of AddURL method into Sitemaps.
+// See ShardByPathPrefix, ShardByLastMod, ShardByHash and ShardByContentType.
+func (s *SitemapIndex) SetShardStrategy(shardFunc ShardFunc) {
+	s.shardFunc = shardFunc
+}
+
 // SetSitemapIndexName sets the filename of SitemapIndex which be used to save the xml file.
 // name param must not have .xml extension.
 func (s *SitemapIndex) SetSitemapIndexName(name string) {
@@ -75,14 +150,41 @@ func (s *SitemapIndex) SetSitemapIndexName(name string) {
 // NewSitemap builds a new instance of Sitemap and appends it in SitemapIndex's Sitemaps
 // and sets it's Name nad Hostname
 func (s *SitemapIndex) NewSitemap() *Sitemap {
-	sm := NewSitemap(s.prettyPrint)
+	sm := NewSitemap(false)
+	if s.prettyPrint {
+		sm.setIndent(s.indentation())
+	}
+	sm.namespaces = s.namespaces
+	if s.maxURLsCount > 0 {
+		sm.maxURLsCount = s.maxURLsCount
+	}
+	if s.maxFileBytes > 0 {
+		sm.maxFileBytes = s.maxFileBytes
+	}
+	sm.maxGzipBytes = s.maxGzipBytes
 	s.Sitemaps = append(s.Sitemaps, sm)
 
 	fileNum := len(s.Sitemaps)
 	sm.SetName(fmt.Sprintf("sitemap%d", fileNum))
 	sm.SetHostname(s.Hostname)
 	sm.SetOutputPath(s.OutputPath)
+	sm.SetServerURI(s.ServerURI)
+	sm.SetStorage(s.storage)
 	sm.SetCompress(s.Compress)
+	sm.SetFormat(s.format)
+	sm.SetLastModPrecision(s.lastModPrecision)
+	sm.SetLastModLocation(s.lastModLocation)
+	sm.setFilenameTemplate(s.filenameTemplate)
+	sm.SetHashFilenames(s.hashFilenames)
+	sm.setCompressionLevel(s.gzipLevel)
+	sm.SetWriteBothVariants(s.bothVariants)
+	if s.now != nil {
+		sm.SetClock(s.now)
+	}
+	sm.SetLogger(s.log)
+	if s.saveDefaultXSL {
+		sm.SetXSLStylesheet(DefaultSitemapXSLName)
+	}
 	return sm
 }
 
@@ -112,9 +214,23 @@ func (s *SitemapIndex) SetOutputPath(outputPath string) {
 }
 
 // SetServerURI sets the ServerURI for SitemapIndex and it's Sitemaps
-// and sets it as OutputPath of new Sitemap entries built using NewSitemap method.
+// and sets it as ServerURI of new Sitemap entries built using NewSitemap method.
 func (s *SitemapIndex) SetServerURI(serverURI string) {
 	s.ServerURI = serverURI
+	for _, sitemap := range s.Sitemaps {
+		sitemap.SetServerURI(s.ServerURI)
+	}
+}
+
+// SetStorage sets the Storage for SitemapIndex and it's Sitemaps and sets it as
+// Storage of new Sitemap entries built using NewSitemap method.
+// Default is the local file system. MemoryStorage keeps the files in memory.
+func (s *SitemapIndex) SetStorage(storage Storage) {
+	s.storage = storage
+	s.passClock()
+	for _, sitemap := range s.Sitemaps {
+		sitemap.SetStorage(s.storage)
+	}
 }
 
 // SetCompress sets the Compress option to be either enabled or disabled for SitemapIndex
@@ -127,18 +243,161 @@ func (s *SitemapIndex) SetCompress(compress bool) {
 	}
 }
 
+// SetLastModPrecision sets the W3C Datetime precision of lastmod values for SitemapIndex
+// and it's Sitemaps and sets it as precision of new Sitemap entries built using NewSitemap method.
+func (s *SitemapIndex) SetLastModPrecision(precision TimePrecision) {
+	s.lastModPrecision = precision
+	for _, sitemap := range s.Sitemaps {
+		sitemap.SetLastModPrecision(s.lastModPrecision)
+	}
+}
+
+// SetLastModLocation sets the timezone of lastmod values for SitemapIndex and it's Sitemaps
+// and sets it as timezone of new Sitemap entries built using NewSitemap method.
+// nil keeps the original timezone of each value.
+func (s *SitemapIndex) SetLastModLocation(loc *time.Location) {
+	s.lastModLocation = loc
+	for _, sitemap := range s.Sitemaps {
+		sitemap.SetLastModLocation(s.lastModLocation)
+	}
+}
+
+// SetFilenameTemplate sets the filename template for Sitemaps of SitemapIndex and sets it
+// as filename template of new Sitemap entries built using NewSitemap method.
+// The SitemapIndex file itself is still named by it's Name. See Sitemap.SetFilenameTemplate.
+func (s *SitemapIndex) SetFilenameTemplate(template string) error {
+	t, err := parseFilenameTemplate(template)
+	if err != nil {
+		return err
+	}
+	s.filenameTemplate = t
+	for _, sitemap := range s.Sitemaps {
+		sitemap.setFilenameTemplate(t)
+	}
+	return nil
+}
+
+// SetHashFilenames enables content hash filenames for Sitemaps of SitemapIndex and sets it
+// for new Sitemap entries built using NewSitemap method. The SitemapIndex file keeps it's Name,
+// so only it needs a short cache TTL. See Sitemap.SetHashFilenames.
+func (s *SitemapIndex) SetHashFilenames(enabled bool) {
+	s.hashFilenames = enabled
+	for _, sitemap := range s.Sitemaps {
+		sitemap.SetHashFilenames(enabled)
+	}
+}
+
+// SetCompressionLevel sets the gzip level of compressed files for SitemapIndex and it's Sitemaps
+// and sets it as gzip level of new Sitemap entries built using NewSitemap method.
+// See Sitemap.SetCompressionLevel.
+func (s *SitemapIndex) SetCompressionLevel(level int) error {
+	err := checkCompressionLevel(level)
+	if err != nil {
+		return err
+	}
+	s.gzipLevel = level
+	for _, sitemap := range s.Sitemaps {
+		sitemap.setCompressionLevel(level)
+	}
+	return nil
+}
+
+// SetWriteBothVariants enables writing both the uncompressed and the gzip compressed variants
+// of the files of SitemapIndex and it's Sitemaps and sets it for new Sitemap entries built using
+// NewSitemap method. Compress of each file designates the variant which is referenced.
+// See Sitemap.SetWriteBothVariants.
+func (s *SitemapIndex) SetWriteBothVariants(enabled bool) {
+	s.bothVariants = enabled
+	for _, sitemap := range s.Sitemaps {
+		sitemap.SetWriteBothVariants(enabled)
+	}
+}
+
+// SetClock sets the clock which is used instead of time.Now for SitemapIndex and it's Sitemaps
+// and sets it as clock of new Sitemap entries built using NewSitemap method.
+// FixedClock makes the output reproducible. See Sitemap.SetClock.
+// The clock is passed on to the Storage which has a clock like MemoryStorage.
+func (s *SitemapIndex) SetClock(now func() time.Time) {
+	s.now = now
+	s.passClock()
+	for _, sitemap := range s.Sitemaps {
+		sitemap.SetClock(now)
+	}
+}
+
+// SetLogger sets the Logger for SitemapIndex and it's Sitemaps and sets it as Logger of
+// new Sitemap entries built using NewSitemap method. The default Logger is silent.
+func (s *SitemapIndex) SetLogger(logger Logger) {
+	s.log = logger
+	for _, sitemap := range s.Sitemaps {
+		sitemap.SetLogger(logger)
+	}
+}
+
+// RegisterNamespace registers an XML namespace for the Extensions of SitemapLoc in Sitemaps of
+// SitemapIndex and registers it for new Sitemap entries built using NewSitemap method.
+// See Sitemap.RegisterNamespace.
+func (s *SitemapIndex) RegisterNamespace(prefix, uri string) error {
+	registered, err := checkNamespace(s.namespaces, prefix, uri)
+	if err != nil {
+		return err
+	}
+	if !registered {
+		s.namespaces = append(s.namespaces[:len(s.namespaces):len(s.namespaces)], xmlNamespace{prefix: prefix, uri: uri})
+	}
+	for _, sitemap := range s.Sitemaps {
+		err = sitemap.RegisterNamespace(prefix, uri)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetManifestName enables saving a JSON Manifest by Save method which lists the written files,
+// their public URLs, URL counts, sizes, SHA-256 checksums and lastmod ranges.
+// name param must not have .json extension. Empty name disables it.
+func (s *SitemapIndex) SetManifestName(name string) {
+	s.manifestName = name
+}
+
+// Manifest returns the Manifest of the last saved files.
+// it is nil before calling the Save method with a manifest name.
+func (s *SitemapIndex) Manifest() *Manifest {
+	return s.manifest
+}
+
+// SetXSLStylesheet sets the href of an XSL stylesheet which is emitted as an
+// xml-stylesheet processing instruction after the xml header of SitemapIndex.
+// it does not change the Sitemaps, use Sitemap.SetXSLStylesheet for them.
+func (s *SitemapIndex) SetXSLStylesheet(href string) {
+	s.xslTag = xslStylesheetTag(href)
+}
+
+// SetDefaultXSLStylesheets enables the embedded default XSL stylesheets for SitemapIndex
+// and it's Sitemaps and sets it for new Sitemap entries built using NewSitemap method.
+// Both stylesheets are saved into OutputPath by Save method using DefaultSitemapIndexXSLName
+// and DefaultSitemapXSLName and are referenced by relative hrefs.
+func (s *SitemapIndex) SetDefaultXSLStylesheets() {
+	s.SetXSLStylesheet(DefaultSitemapIndexXSLName)
+	s.saveDefaultXSL = true
+	for _, sitemap := range s.Sitemaps {
+		sitemap.SetXSLStylesheet(DefaultSitemapXSLName)
+	}
+}
+
 // WriteTo writes XML encoded sitemap to given io.Writer.
 // Implements io.WriterTo interface.
 func (s *SitemapIndex) WriteTo(writer io.Writer) (int64, error) {
-	headerCount, err := writer.Write([]byte(xml.Header))
+	headerCount, err := writer.Write([]byte(xml.Header + s.xslTag))
 	if err != nil {
 		return 0, err
 	}
 	encoder := xml.NewEncoder(writer)
 	if s.prettyPrint {
-		encoder.Indent("", "  ")
+		encoder.Indent("", s.indentation())
 	}
-	err = encoder.Encode(s)
+	err = encoder.Encode(s.encodable())
 	if err != nil {
 		return 0, err
 	}
@@ -150,10 +409,36 @@ func (s *SitemapIndex) WriteTo(writer io.Writer) (int64, error) {
 	return int64(headerCount + bodyCount), err
 }
 
+// encodable builds the encoded form of SitemapIndex with formatted lastmod values.
+func (s *SitemapIndex) encodable() *xmlSitemapIndex {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	index := &xmlSitemapIndex{
+		Xmlns:       s.Xmlns,
+		SitemapLocs: make([]*xmlSitemapIndexLoc, len(s.SitemapLocs)),
+	}
+	for i, loc := range s.SitemapLocs {
+		index.SitemapLocs[i] = &xmlSitemapIndexLoc{
+			Loc:     loc.Loc,
+			LastMod: s.formatLastMod(loc.LastMod),
+		}
+	}
+	return index
+}
+
 // Save makes the OutputPath in case of absence and saves the SitemapIndex
 // and it's Sitemaps into OutputPath as separate files using their Name.
+// it fails without writing any file in case of two files with the same name.
 func (s *SitemapIndex) Save() (string, error) {
-	err := checkAndMakeDir(s.OutputPath)
+	var filename string
+	if s.Compress {
+		filename = s.Name + fileGzExt
+	} else {
+		filename = s.Name + fileExt
+	}
+
+	err := s.checkFilenames(filename)
 	if err != nil {
 		return "", err
 	}
@@ -163,11 +448,11 @@ func (s *SitemapIndex) Save() (string, error) {
 		return "", err
 	}
 
-	var filename string
-	if s.Compress {
-		filename = s.Name + fileGzExt
-	} else {
-		filename = s.Name + fileExt
+	if s.saveDefaultXSL {
+		err = s.saveDefaultXSLs()
+		if err != nil {
+			return "", err
+		}
 	}
 
 	buf := bytes.Buffer{}
@@ -175,51 +460,154 @@ func (s *SitemapIndex) Save() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	_, err = writeToFile(filename, s.OutputPath, s.Compress, buf.Bytes())
-	// s.finalURL = filepath.Join(s.Hostname, s.OutputPath, filename)
+	s.savedFiles, err = s.writeFile(filename, buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+
+	if s.manifestName != "" {
+		err = s.saveManifest()
+		if err != nil {
+			return "", err
+		}
+	}
 
+	// SitemapIndex is saved alongside it's Sitemaps, so it has the same ServerURI
+	s.indexURL, err = publicURL(s.Hostname, s.ServerURI, filename)
+	if err != nil {
+		return "", err
+	}
+	// the URL which is pinged is made of OutputPath as before for compatibility
 	output, err := url.Parse(s.Hostname)
 	if err != nil {
 		return "", err
 	}
 	output.Path = path.Join(output.Path, s.OutputPath, filename)
 	s.finalURL = output.String()
-
-	return filename, err
+	s.logger().Info("sitemap index is saved", "filename", filename, "sitemaps", len(s.SitemapLocs))
+	return filename, nil
 }
 
+// saveSitemaps saves the Sitemaps concurrently and replaces the SitemapLocs of the previous Save
+// with their new locations in the order of Sitemaps, so the output does not depend on scheduling.
+// it returns the error of the first failed Sitemap.
 func (s *SitemapIndex) saveSitemaps() error {
-	for _, sitemap := range s.Sitemaps {
+	locs := make([][]*SitemapIndexLoc, len(s.Sitemaps))
+	errs := make([]error, len(s.Sitemaps))
+	for i, sitemap := range s.Sitemaps {
 		s.wg.Add(1)
-		go func(sm *Sitemap) {
+		go func(i int, sm *Sitemap) {
 			defer s.wg.Done()
 
 			smFilenames, err := sm.Save()
 			if err != nil {
-				log.Println("Error while saving this sitemap:", sm.Name, err)
+				s.logger().Error("error while saving sitemap", "name", sm.Name, "error", err)
+				errs[i] = fmt.Errorf("sitemap %s: %w", sm.Name, err)
 				return
 			}
 			for _, smFilename := range smFilenames {
-				// sm.SitemapIndexLoc.Loc = filepath.Join(s.Hostname, s.ServerURI, smFilename)
-
-				output, err := url.Parse(s.Hostname)
+				loc, err := publicURL(s.Hostname, s.ServerURI, smFilename)
 				if err != nil {
-					log.Println("Error while saving this sitemap:", sm.Name, err)
+					s.logger().Error("error while saving sitemap", "name", sm.Name, "error", err)
+					errs[i] = fmt.Errorf("sitemap %s: %w", sm.Name, err)
 					return
 				}
-				output.Path = path.Join(output.Path, s.ServerURI, smFilename)
-				smIndexLoc := &SitemapIndexLoc{
-					Loc: output.String(),
+				smIndexLoc := &SitemapIndexLoc{Loc: loc}
+				// the lastmod is emitted only in case of being set by SetLastMod
+				if sm.lastModSet {
+					smIndexLoc.LastMod = sm.SitemapIndexLoc.LastMod
 				}
-				s.Add(smIndexLoc)
+				locs[i] = append(locs[i], smIndexLoc)
 			}
-		}(sitemap)
+		}(i, sitemap)
 	}
 	s.wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	sitemapLocs := make([]*SitemapIndexLoc, 0, len(s.SitemapLocs))
+	for _, loc := range s.SitemapLocs {
+		if !s.savedLocs[loc] {
+			sitemapLocs = append(sitemapLocs, loc)
+		}
+	}
+	s.savedLocs = make(map[*SitemapIndexLoc]bool)
+	for _, smLocs := range locs {
+		for _, loc := range smLocs {
+			s.savedLocs[loc] = true
+			sitemapLocs = append(sitemapLocs, loc)
+		}
+	}
+	s.SitemapLocs = sitemapLocs
 	return nil
 }
 
-// PingSearchEngines pings search engines
+// checkFilenames returns an error in case of collision of the files of Sitemaps and
+// the SitemapIndex file which is named filename. The default names do not collide,
+// it guards against the custom names and filename templates.
+func (s *SitemapIndex) checkFilenames(filename string) error {
+	owners := make(map[string]string)
+	for _, name := range s.variantFilenames(filename) {
+		owners[filepath.Join(s.OutputPath, name)] = "sitemap index " + s.Name
+	}
+	for _, sm := range s.Sitemaps {
+		smFilenames, err := sm.partFilenames()
+		if err != nil {
+			return err
+		}
+		for _, smFilename := range smFilenames {
+			for _, name := range sm.variantFilenames(smFilename) {
+				key := filepath.Join(sm.OutputPath, name)
+				if owner, ok := owners[key]; ok {
+					return fmt.Errorf("filename %s of sitemap %s collides with %s", name, sm.Name, owner)
+				}
+				owners[key] = "sitemap " + sm.Name
+			}
+		}
+	}
+	return nil
+}
+
+// saveDefaultXSLs saves the default XSL stylesheets of SitemapIndex and Sitemaps into OutputPath.
+func (s *SitemapIndex) saveDefaultXSLs() error {
+	indexXSL, err := writeToFile(s.fileStorage(), DefaultSitemapIndexXSLName, s.OutputPath, gzip.NoCompression, defaultSitemapIndexXSL)
+	if err != nil {
+		return err
+	}
+	sitemapXSL, err := writeToFile(s.fileStorage(), DefaultSitemapXSLName, s.OutputPath, gzip.NoCompression, defaultSitemapXSL)
+	if err != nil {
+		return err
+	}
+	indexXSL.gzipSize = compressedLen(s.compressionLevel(true), defaultSitemapIndexXSL)
+	sitemapXSL.gzipSize = compressedLen(s.compressionLevel(true), defaultSitemapXSL)
+	s.xslFiles = []*savedFile{indexXSL, sitemapXSL}
+	return nil
+}
+
+// FinalURL returns the public URL of the saved SitemapIndex file
+// which is made of Hostname, ServerURI and filename.
+// it is empty before calling the Save method.
+func (s *SitemapIndex) FinalURL() string {
+	return s.indexURL
+}
+
+// UpdateRobotsTxt writes or updates the robots.txt file in filename path
+// with a Sitemap directive pointing at the public URL of SitemapIndex.
+// The Save method must be called before. See UpdateRobotsTxt function.
+func (s *SitemapIndex) UpdateRobotsTxt(filename string) error {
+	if s.indexURL == "" {
+		return errors.New("the save method must be called before updating robots.txt")
+	}
+	return UpdateRobotsTxt(filename, s.indexURL)
+}
+
+// PingSearchEngines pings search engines with the URL of SitemapIndex which is made of
+// Hostname, OutputPath and filename. pingURLs are formats which contain a %s for the URL.
 func (s *SitemapIndex) PingSearchEngines(pingURLs ...string) error {
 	if s.finalURL == "" {
 		return errors.New("the save method must be called before ping")
@@ -234,15 +622,15 @@ func (s *SitemapIndex) PingSearchEngines(pingURLs ...string) error {
 			defer wg.Done()
 			
 			urlStr := fmt.Sprintf(urlFormat, s.finalURL)
-			log.Println("Pinging", urlStr)
+			s.logger().Info("pinging", "url", urlStr)
 
 			resp, err := client.Get(urlStr)
 			if err != nil {
-				log.Println("Failed to Ping:", urlStr)
+				s.logger().Warn("failed to ping", "url", urlStr, "error", err)
 				return
 			}
 			resp.Body.Close()
-			log.Println("Successful Ping:", urlStr)
+			s.logger().Info("successful ping", "url", urlStr)
 		}(pingURL)
 	}
 	wg.Wait()
@@ -1,14 +1,22 @@
 package smg
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/xml"
 	"fmt"
 	"io"
+	"io/fs"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 const (
@@ -156,9 +164,9 @@ func TestLargeURLSetSitemap(t *testing.T) {
 	// Checking the larger sitemap which was no-name, file no. 1:
 	assertOutputFile(t, path, "large"+fileExt)
 	//  file no. 2:
-	assertOutputFile(t, path, "large1"+fileExt)
+	assertOutputFile(t, path, "large-2"+fileExt)
 	//  file no. 3:
-	assertOutputFile(t, path, "large2"+fileExt)
+	assertOutputFile(t, path, "large-3"+fileExt)
 }
 
 // TestLargeURLSetSitemap tests another one with 100001 items to be split to five files max 25k each
@@ -242,7 +250,7 @@ func TestBigSizeSitemap(t *testing.T) {
 
 	assertOutputFile(t, path, "big"+fileExt)
 	// no. 2:
-	assertOutputFile(t, path, "big1"+fileExt)
+	assertOutputFile(t, path, "big-2"+fileExt)
 }
 
 // TestSitemapIndexSave tests that on SitemapIndex.Save(), function produces a proper URL path to the sitemap
@@ -352,7 +360,7 @@ func assertOutputFile(t *testing.T, path, name string) {
 	}
 	if f.Size() == 0 {
 		t.Fatal("Zero size:", name)
-	} else if f.Size() > int64(maxFileSize) {
+	} else if f.Size() > int64(MaxFileSize) {
 		t.Fatal("Size is more than limits:", name, f.Size())
 	}
 }
@@ -378,3 +386,284 @@ func randString(n int) string {
 	}
 	return string(b)
 }
+
+// TestSitemapIndexLastModPrecision tests that the lastmod values of SitemapIndex entries are formatted
+func TestSitemapIndexLastModPrecision(t *testing.T) {
+	path := t.TempDir()
+	lastMod := time.Date(2022, 2, 12, 16, 29, 46, 450130000, time.UTC)
+
+	smi := NewSitemapIndex(false)
+	smi.SetCompress(false)
+	smi.SetHostname(baseURL)
+	smi.SetOutputPath(path)
+	smi.SetLastModPrecision(PrecisionMinutes)
+
+	sm := smi.NewSitemap()
+	sm.SetLastMod(&lastMod)
+	err := sm.Add(&SitemapLoc{Loc: "/test", LastMod: &lastMod})
+	if err != nil {
+		t.Fatal("Unable to add SitemapLoc:", err)
+	}
+
+	indexFilename, err := smi.Save()
+	if err != nil {
+		t.Fatal("Unable to Save SitemapIndex:", err)
+	}
+	byteValue, err := os.ReadFile(filepath.Join(path, indexFilename))
+	if err != nil {
+		t.Fatal("Unable to read file:", err)
+	}
+	var sitemapIndex SitemapIndexXml
+	err = xml.Unmarshal(byteValue, &sitemapIndex)
+	if err != nil {
+		t.Fatal("Unable to unmarhsall sitemap byte array into xml: ", err)
+	}
+	if actual := sitemapIndex.Sitemaps[0].LasMod; actual != "2022-02-12T16:29Z" {
+		t.Fatal("LastMod Mismatch:", actual)
+	}
+}
+
+// TestSitemapIndexXSLStylesheets tests that the default XSL stylesheets are referenced and saved by SitemapIndex
+func TestSitemapIndexXSLStylesheets(t *testing.T) {
+	path := t.TempDir()
+
+	smi := NewSitemapIndex(false)
+	smi.SetCompress(false)
+	smi.SetHostname(baseURL)
+	smi.SetOutputPath(path)
+	smi.SetDefaultXSLStylesheets()
+
+	sm := smi.NewSitemap()
+	err := sm.Add(&SitemapLoc{Loc: "/test"})
+	if err != nil {
+		t.Fatal("Unable to add SitemapLoc:", err)
+	}
+
+	indexFilename, err := smi.Save()
+	if err != nil {
+		t.Fatal("Unable to Save SitemapIndex:", err)
+	}
+	assertOutputFile(t, path, DefaultSitemapIndexXSLName)
+	assertOutputFile(t, path, DefaultSitemapXSLName)
+
+	indexContent, err := os.ReadFile(filepath.Join(path, indexFilename))
+	if err != nil {
+		t.Fatal("Unable to read file:", err)
+	}
+	if !strings.Contains(string(indexContent), `<?xml-stylesheet type="text/xsl" href="sitemapindex.xsl"?>`) {
+		t.Fatal("SitemapIndex has no xml-stylesheet instruction")
+	}
+	smContent, err := os.ReadFile(filepath.Join(path, "sitemap1"+fileExt))
+	if err != nil {
+		t.Fatal("Unable to read file:", err)
+	}
+	if !strings.Contains(string(smContent), `<?xml-stylesheet type="text/xsl" href="sitemap.xsl"?>`) {
+		t.Fatal("Sitemap has no xml-stylesheet instruction")
+	}
+}
+
+// TestSitemapIndexTextSitemap tests that text format Sitemaps are referenced in SitemapIndex
+func TestSitemapIndexTextSitemap(t *testing.T) {
+	path := t.TempDir()
+
+	smi := NewSitemapIndex(false)
+	smi.SetCompress(false)
+	smi.SetHostname(baseURL)
+	smi.SetOutputPath(path)
+
+	sm := smi.NewSitemap()
+	sm.SetFormat(FormatText)
+	err := sm.Add(&SitemapLoc{Loc: "/test"})
+	if err != nil {
+		t.Fatal("Unable to add SitemapLoc:", err)
+	}
+
+	indexFilename, err := smi.Save()
+	if err != nil {
+		t.Fatal("Unable to Save SitemapIndex:", err)
+	}
+	assertOutputFile(t, path, "sitemap1"+fileTxtExt)
+
+	byteValue, err := os.ReadFile(filepath.Join(path, indexFilename))
+	if err != nil {
+		t.Fatal("Unable to read file:", err)
+	}
+	var sitemapIndex SitemapIndexXml
+	err = xml.Unmarshal(byteValue, &sitemapIndex)
+	if err != nil {
+		t.Fatal("Unable to unmarhsall sitemap byte array into xml: ", err)
+	}
+	if actual := sitemapIndex.Sitemaps[0].Loc; actual != baseURL+"/sitemap1.txt" {
+		t.Fatal("URL Mismatch:", actual)
+	}
+}
+
+// TestSitemapIndexCompressionVariants tests writing both variants with a gzip level and deterministic headers
+func TestSitemapIndexCompressionVariants(t *testing.T) {
+	save := func() *MemoryStorage {
+		storage := NewMemoryStorage()
+		smi := NewSitemapIndex(false)
+		smi.SetHostname(baseURL)
+		smi.SetStorage(storage)
+		smi.SetWriteBothVariants(true)
+		assert.NoError(t, smi.SetCompressionLevel(gzip.BestCompression))
+		sm := smi.NewSitemap()
+		sm.SetLastMod(nil)
+		assert.NoError(t, sm.Add(&SitemapLoc{Loc: "/a"}))
+		_, err := smi.Save()
+		if err != nil {
+			t.Fatal("Unable to Save SitemapIndex:", err)
+		}
+		return storage
+	}
+	storage := save()
+	assert.ElementsMatch(t, []string{"sitemap.xml", "sitemap.xml.gz", "sitemap1.xml", "sitemap1.xml.gz"}, storage.Filenames())
+
+	index, err := storage.ReadFile("sitemap.xml")
+	if err != nil {
+		t.Fatal("Unable to read sitemap index:", err)
+	}
+	assert.Contains(t, string(index), "<loc>"+baseURL+"/sitemap1.xml.gz</loc>")
+
+	compressed, err := storage.ReadFile("sitemap1.xml.gz")
+	if err != nil {
+		t.Fatal("Unable to read sitemap:", err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal("Unable to read gzip header:", err)
+	}
+	assert.Empty(t, r.Name)
+	assert.True(t, r.ModTime.IsZero())
+	uncompressed, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal("Unable to decompress sitemap:", err)
+	}
+	raw, err := storage.ReadFile("sitemap1.xml")
+	if err != nil {
+		t.Fatal("Unable to read sitemap:", err)
+	}
+	assert.Equal(t, raw, uncompressed)
+
+	again, err := save().ReadFile("sitemap1.xml.gz")
+	if err != nil {
+		t.Fatal("Unable to read sitemap:", err)
+	}
+	assert.Equal(t, compressed, again)
+
+	smi := NewSitemapIndex(false)
+	assert.Error(t, smi.SetCompressionLevel(gzip.NoCompression))
+	assert.Error(t, smi.SetCompressionLevel(10))
+}
+
+// TestSitemapIndexClockStorage tests that the clock is used by new Sitemaps and passed on to the Storage
+func TestSitemapIndexClockStorage(t *testing.T) {
+	buildTime := time.Date(2022, 2, 12, 0, 0, 0, 0, time.UTC)
+	for _, clockFirst := range []bool{true, false} {
+		storage := NewMemoryStorage()
+		smi := NewSitemapIndex(false)
+		if clockFirst {
+			smi.SetClock(FixedClock(buildTime))
+			smi.SetStorage(storage)
+		} else {
+			smi.SetStorage(storage)
+			smi.SetClock(FixedClock(buildTime))
+		}
+		sm := smi.NewSitemap()
+		assert.Equal(t, buildTime, *sm.SitemapIndexLoc.LastMod)
+		assert.NoError(t, sm.Add(&SitemapLoc{Loc: "/a"}))
+		_, err := smi.Save()
+		if err != nil {
+			t.Fatal("Unable to Save SitemapIndex:", err)
+		}
+		for _, filename := range storage.Filenames() {
+			info, err := fs.Stat(storage, filename)
+			if err != nil {
+				t.Fatal("Unable to stat file:", err)
+			}
+			assert.Equal(t, buildTime, info.ModTime(), filename)
+		}
+	}
+}
+
+// TestSitemapIndexReproducible tests that the same input is saved as the same bytes using a fixed clock
+func TestSitemapIndexReproducible(t *testing.T) {
+	buildTime := time.Date(2022, 2, 12, 0, 0, 0, 0, time.UTC)
+	save := func() (*SitemapIndex, *MemoryStorage) {
+		storage := NewMemoryStorage()
+		smi := NewSitemapIndex(true)
+		smi.SetHostname(baseURL)
+		smi.SetStorage(storage)
+		smi.SetManifestName("manifest")
+		smi.SetClock(FixedClock(buildTime))
+		assert.NoError(t, smi.SetFilenameTemplate("{name}-{part}"))
+		for i := 0; i < 20; i++ {
+			sm := smi.NewSitemap()
+			sm.SetMaxURLsCount(1)
+			assert.NoError(t, sm.Add(&SitemapLoc{Loc: fmt.Sprintf("/a/%d", i)}))
+			assert.NoError(t, sm.Add(&SitemapLoc{Loc: fmt.Sprintf("/b/%d", i)}))
+		}
+		_, err := smi.Save()
+		if err != nil {
+			t.Fatal("Unable to Save SitemapIndex:", err)
+		}
+		return smi, storage
+	}
+
+	smi, first := save()
+	_, second := save()
+	assert.ElementsMatch(t, first.Filenames(), second.Filenames())
+	for _, filename := range first.Filenames() {
+		content, _ := first.ReadFile(filename)
+		other, _ := second.ReadFile(filename)
+		assert.Equal(t, content, other, filename)
+	}
+	assert.Nil(t, smi.SitemapLocs[0].LastMod)
+	// the Sitemaps are listed in their order and their parts in ascending order
+	locs := make([]string, 0, len(smi.SitemapLocs))
+	for i := 1; i <= 20; i++ {
+		locs = append(locs, fmt.Sprintf("%s/sitemap%d-1.xml.gz", baseURL, i), fmt.Sprintf("%s/sitemap%d-2.xml.gz", baseURL, i))
+	}
+	for i, loc := range smi.SitemapLocs {
+		assert.Equal(t, locs[i], loc.Loc)
+	}
+
+	// saving again replaces the locations of Sitemaps and keeps the added ones
+	smi.Add(&SitemapIndexLoc{Loc: baseURL + "/external.xml"})
+	_, err := smi.Save()
+	if err != nil {
+		t.Fatal("Unable to Save SitemapIndex:", err)
+	}
+	assert.Len(t, smi.SitemapLocs, 41)
+	assert.Equal(t, baseURL+"/external.xml", smi.SitemapLocs[0].Loc)
+}
+
+// TestSitemapIndexPingURL tests that the search engines are pinged with the URL made of OutputPath
+// as before, while FinalURL is the public URL made of ServerURI
+func TestSitemapIndexPingURL(t *testing.T) {
+	pinged := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pinged <- r.URL.Query().Get("sitemap")
+	}))
+	defer server.Close()
+	defaultPingURLs := searchEnginePingURLs
+	searchEnginePingURLs = nil
+	defer func() { searchEnginePingURLs = defaultPingURLs }()
+
+	smi := NewSitemapIndex(false)
+	smi.SetHostname(baseURL)
+	smi.SetStorage(NewMemoryStorage())
+	smi.SetOutputPath("public/sitemaps")
+	smi.SetServerURI("/sitemaps/")
+	smi.SetSitemapIndexName("index")
+	assert.NoError(t, smi.NewSitemap().Add(&SitemapLoc{Loc: "/test"}))
+	_, err := smi.Save()
+	if err != nil {
+		t.Fatal("Unable to Save SitemapIndex:", err)
+	}
+	assert.Equal(t, baseURL+"/sitemaps/index.xml.gz", smi.FinalURL())
+
+	err = smi.PingSearchEngines(server.URL + "/ping?sitemap=%s")
+	assert.NoError(t, err)
+	assert.Equal(t, baseURL+"/public/sitemaps/index.xml.gz", <-pinged)
+}
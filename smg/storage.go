@@ -0,0 +1,155 @@
+package smg
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Storage is used by the Save methods for writing the output files.
+// path is the OutputPath and filename is the name of file with extension.
+// The default Storage writes the files into the local file system.
+type Storage interface {
+	WriteFile(path, filename string, content []byte) error
+}
+
+// dirStorage is the default Storage which writes the files into the local file system.
+type dirStorage struct{}
+
+// WriteFile makes the path in case of absence and writes the file into it.
+func (dirStorage) WriteFile(path, filename string, content []byte) error {
+	err := checkAndMakeDir(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(path, filename), content, 0666)
+}
+
+// MemoryStorage is a Storage which keeps the output files in memory.
+// It implements fs.FS, so the files can be served using Handler
+// without writing them into the file system.
+// Files are keyed by the slash separated path.Join(path, filename).
+type MemoryStorage struct {
+	files map[string]*memoryFile
+	now   func() time.Time
+	mutex sync.RWMutex
+}
+
+type memoryFile struct {
+	content []byte
+	modTime time.Time
+}
+
+// NewMemoryStorage builds and returns a new empty MemoryStorage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		files: make(map[string]*memoryFile),
+	}
+}
+
+// WriteFile keeps a copy of content as the file in memory.
+func (m *MemoryStorage) WriteFile(dir, filename string, content []byte) error {
+	name := path.Join(filepath.ToSlash(dir), filename)
+	file := &memoryFile{
+		content: append([]byte(nil), content...),
+		modTime: m.currentTime().UTC(),
+	}
+
+	m.mutex.Lock()
+	m.files[name] = file
+	m.mutex.Unlock()
+	return nil
+}
+
+// SetClock sets the clock which is used instead of time.Now for the modification times of files.
+func (m *MemoryStorage) SetClock(now func() time.Time) {
+	m.mutex.Lock()
+	m.now = now
+	m.mutex.Unlock()
+}
+
+func (m *MemoryStorage) currentTime() time.Time {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	if m.now == nil {
+		return time.Now()
+	}
+	return m.now()
+}
+
+// ReadFile returns the content of the named file.
+// Implements fs.ReadFileFS interface.
+func (m *MemoryStorage) ReadFile(name string) ([]byte, error) {
+	file, err := m.file("read", name)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), file.content...), nil
+}
+
+// Open opens the named file for reading. Only files are supported and
+// directories are reported as not existing. Implements fs.FS interface.
+func (m *MemoryStorage) Open(name string) (fs.File, error) {
+	file, err := m.file("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return &openMemoryFile{
+		Reader: bytes.NewReader(file.content),
+		info: &memoryFileInfo{
+			name:    path.Base(name),
+			size:    int64(len(file.content)),
+			modTime: file.modTime,
+		},
+	}, nil
+}
+
+// Filenames returns the names of all files in MemoryStorage.
+func (m *MemoryStorage) Filenames() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	names := make([]string, 0, len(m.files))
+	for name := range m.files {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (m *MemoryStorage) file(op, name string) (*memoryFile, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	m.mutex.RLock()
+	file, ok := m.files[name]
+	m.mutex.RUnlock()
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return file, nil
+}
+
+type openMemoryFile struct {
+	*bytes.Reader
+	info *memoryFileInfo
+}
+
+func (f *openMemoryFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *openMemoryFile) Close() error               { return nil }
+
+type memoryFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (i *memoryFileInfo) Name() string       { return i.name }
+func (i *memoryFileInfo) Size() int64        { return i.size }
+func (i *memoryFileInfo) Mode() fs.FileMode  { return 0444 }
+func (i *memoryFileInfo) ModTime() time.Time { return i.modTime }
+func (i *memoryFileInfo) IsDir() bool        { return false }
+func (i *memoryFileInfo) Sys() interface{}   { return nil }
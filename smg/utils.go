@@ -1,11 +1,61 @@
 package smg
 
 import (
+	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
 	"os"
-	"path/filepath"
+	"path"
 )
 
+// publicURL builds the public URL of a saved file using the hostname
+// and the serverURI which is the path of file on the server.
+func publicURL(hostname, serverURI, filename string) (string, error) {
+	output, err := url.Parse(hostname)
+	if err != nil {
+		return "", err
+	}
+	output.Path = path.Join(output.Path, serverURI, filename)
+	return output.String(), nil
+}
+
+// resolveLoc resolves the loc reference against the hostname and
+// returns the absolute URL of loc.
+func resolveLoc(hostname, loc string) (string, error) {
+	output, err := url.Parse(hostname)
+	if err != nil {
+		return "", err
+	}
+	locURL, err := url.Parse(loc)
+	if err != nil {
+		return "", err
+	}
+	return output.ResolveReference(locURL).String(), nil
+}
+
+// resolveImageLoc returns the absolute URL of an image loc. Absolute image URLs
+// are kept as they are and relative ones are joined to the path of hostname,
+// e.g. "img.png" of "https://example.com/blog" is "https://example.com/blog/img.png".
+func resolveImageLoc(hostname, loc string) (string, error) {
+	locURL, err := url.Parse(loc)
+	if err != nil {
+		return "", err
+	}
+	if locURL.IsAbs() || locURL.Host != "" {
+		return resolveLoc(hostname, loc)
+	}
+	output, err := url.Parse(hostname)
+	if err != nil {
+		return "", err
+	}
+	output.Path = path.Join(output.Path, locURL.Path)
+	output.RawQuery = locURL.RawQuery
+	output.Fragment = locURL.Fragment
+	return output.String(), nil
+}
+
 // checkAndMakeDir makes the path in case of absence of the OutputPath
 func checkAndMakeDir(path string) error {
 	if _, err := os.Stat(path); path != "" && os.IsNotExist(err) {
@@ -17,38 +67,63 @@ func checkAndMakeDir(path string) error {
 	return nil
 }
 
-// writeToFile uses an io.WriterTo interface param to write the Sitemap file.
-// writer param cab be a Sitemap or SitemapIndex instance.
-// filename param is a full filename with extension and path is the dir path.
-// compress defines whether the file must be gzip compressed or not.
-// returns n for number of written bytes and error in case of any problem.
-func writeToFile(filename, path string, compress bool, content ...[]byte) (n int, err error) {
-	file, err := os.OpenFile(filepath.Join(path, filename), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
-	if err != nil {
-		return 0, err
-	}
-	defer file.Close()
+// savedFile describes a file which is written by writeToFile.
+type savedFile struct {
+	filename   string
+	compressed bool
+	rawSize    int
+	size       int
+	gzipSize   int
+	sha256     string
+}
 
+// writeToFile uses the Storage to write the content as a file.
+// filename param is a full filename with extension and path is the dir path.
+// level is the gzip level of compressed file or gzip.NoCompression for the uncompressed file.
+// The gzip header has neither name nor modification time, so the same content is always
+// written as the same bytes.
+// returns the sizes and checksum of the written file and error in case of any problem.
+func writeToFile(storage Storage, filename, path string, level int, content ...[]byte) (*savedFile, error) {
+	n := 0
+	buf := bytes.Buffer{}
+	compress := level != gzip.NoCompression
 	if compress {
-		w := gzip.NewWriter(file)
-		defer w.Close()
-
+		w, err := gzip.NewWriterLevel(&buf, level)
+		if err != nil {
+			return nil, err
+		}
 		for _, bytes := range content {
 			tn, err := w.Write(bytes)
 			if err != nil {
-				return 0, err
+				return nil, err
 			}
 			n += tn
 		}
-		return
-	}
-
-	for _, bytes := range content {
-		tn, err := file.Write(bytes)
+		err = w.Close()
 		if err != nil {
-			return 0, err
+			return nil, err
 		}
-		n += tn
+	} else {
+		for _, bytes := range content {
+			tn, _ := buf.Write(bytes)
+			n += tn
+		}
+	}
+
+	err := storage.WriteFile(path, filename, buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	file := &savedFile{
+		filename:   filename,
+		compressed: compress,
+		rawSize:    n,
+		size:       buf.Len(),
+		sha256:     hex.EncodeToString(sum[:]),
+	}
+	if compress {
+		file.gzipSize = file.size
 	}
-	return
+	return file, nil
 }
@@ -0,0 +1,34 @@
+package smg
+
+import (
+	"bytes"
+	_ "embed" // for embedding the default XSL stylesheets
+	"encoding/xml"
+)
+
+// Filenames of the default XSL stylesheets which are saved alongside the
+// Sitemap and SitemapIndex files when the default stylesheets are enabled.
+const (
+	DefaultSitemapXSLName      string = "sitemap.xsl"
+	DefaultSitemapIndexXSLName string = "sitemapindex.xsl"
+)
+
+var (
+	//go:embed xsl/sitemap.xsl
+	defaultSitemapXSL []byte
+	//go:embed xsl/sitemapindex.xsl
+	defaultSitemapIndexXSL []byte
+)
+
+// xslStylesheetTag builds the xml-stylesheet processing instruction of href.
+// returns an empty string in case of empty href.
+func xslStylesheetTag(href string) string {
+	if href == "" {
+		return ""
+	}
+	buf := bytes.Buffer{}
+	buf.WriteString(`<?xml-stylesheet type="text/xsl" href="`)
+	xml.EscapeText(&buf, []byte(href))
+	buf.WriteString("\"?>\n")
+	return buf.String()
+}
@@ -0,0 +1,54 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<xsl:stylesheet version="1.0"
+                xmlns:xsl="http://www.w3.org/1999/XSL/Transform"
+                xmlns:sitemap="http://www.sitemaps.org/schemas/sitemap/0.9"
+                xmlns:image="http://www.google.com/schemas/sitemap-image/1.1"
+                exclude-result-prefixes="sitemap image">
+  <xsl:output method="html" version="5.0" encoding="UTF-8" indent="yes"/>
+  <xsl:template match="/">
+    <html>
+      <head>
+        <title>Sitemap</title>
+        <meta name="viewport" content="width=device-width, initial-scale=1"/>
+        <style>
+          body { font-family: sans-serif; font-size: 14px; color: #333; margin: 2em; }
+          table { border-collapse: collapse; width: 100%; }
+          th, td { text-align: left; padding: 6px 10px; border-bottom: 1px solid #ddd; }
+          th { background: #f5f5f5; }
+          tr:hover td { background: #fafafa; }
+          a { color: #0645ad; text-decoration: none; }
+        </style>
+      </head>
+      <body>
+        <h1>Sitemap</h1>
+        <p>This sitemap contains <xsl:value-of select="count(sitemap:urlset/sitemap:url)"/> URLs.</p>
+        <table>
+          <thead>
+            <tr>
+              <th>#</th>
+              <th>URL</th>
+              <th>Images</th>
+              <th>Last Modified</th>
+              <th>Change Frequency</th>
+              <th>Priority</th>
+            </tr>
+          </thead>
+          <tbody>
+            <xsl:for-each select="sitemap:urlset/sitemap:url">
+              <tr>
+                <td><xsl:value-of select="position()"/></td>
+                <td>
+                  <a href="{sitemap:loc}"><xsl:value-of select="sitemap:loc"/></a>
+                </td>
+                <td><xsl:value-of select="count(image:image)"/></td>
+                <td><xsl:value-of select="sitemap:lastmod"/></td>
+                <td><xsl:value-of select="sitemap:changefreq"/></td>
+                <td><xsl:value-of select="sitemap:priority"/></td>
+              </tr>
+            </xsl:for-each>
+          </tbody>
+        </table>
+      </body>
+    </html>
+  </xsl:template>
+</xsl:stylesheet>
@@ -0,0 +1,235 @@
+package source
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sabloger/sitemap-generator/internal/htmlmeta"
+	"github.com/sabloger/sitemap-generator/smg"
+)
+
+// LastModSource is used for defining the source of lastmod values of WalkDir.
+type LastModSource int
+
+// predefined LastModSource values
+const (
+	// ModTime uses the modification time of files.
+	ModTime LastModSource = iota
+	// ContentHash uses the time of the first walk which has seen the current content
+	// of files. The content hashes are kept in the HashFile of DirRules between walks
+	// which makes lastmod values independent of the file times of fresh checkouts.
+	ContentHash
+	// NoLastMod does not set lastmod values.
+	NoLastMod
+)
+
+// DirRules defines how the files of a static site directory are mapped to URLs by WalkDir.
+// Only the files with Extensions are added. IndexFiles are mapped to the URL of their
+// directory with a trailing slash and the Extensions of the other files are removed
+// in case of StripExtensions. Exclude patterns are matched using path.Match against the
+// slash separated path relative to the root; patterns without "/" are matched against
+// the base name as well and the excluded directories are skipped. ChangeFreq and
+// Priority are set for all the files.
+type DirRules struct {
+	IndexFiles      []string
+	Extensions      []string
+	StripExtensions bool
+	Exclude         []string
+	LastMod         LastModSource
+	HashFile        string
+	ChangeFreq      smg.ChangeFreq
+	Priority        float32
+}
+
+// DefaultDirRules maps the HTML files to URLs without their extension and index.html
+// to the URL of its directory and uses the modification time of files as lastmod.
+var DefaultDirRules = DirRules{
+	IndexFiles:      []string{"index.html", "index.htm"},
+	Extensions:      []string{".html", ".htm"},
+	StripExtensions: true,
+	LastMod:         ModTime,
+}
+
+// hashEntry is a file of the HashFile which keeps the lastmod of the content hash.
+type hashEntry struct {
+	Hash    string    `json:"hash"`
+	LastMod time.Time `json:"lastmod"`
+}
+
+// WalkDir walks the directory of a built static site in lexical order and adds the
+// files into the sink as root-relative URLs which are resolved against the Hostname
+// of Sitemap. The pages which have a noindex robots meta tag are skipped.
+// The HashFile is updated after the walk in case of ContentHash LastMod whose new
+// entries take the time of the clock of sink, e.g. the clock set by Sitemap.SetClock.
+func WalkDir(root string, rules DirRules, sink Sink) error {
+	for _, pattern := range rules.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+	}
+	var hashes, walkHashes map[string]*hashEntry
+	if rules.LastMod == ContentHash {
+		if rules.HashFile == "" {
+			return errors.New("HashFile is required for ContentHash lastmod")
+		}
+		var err error
+		hashes, err = readHashFile(rules.HashFile)
+		if err != nil {
+			return err
+		}
+		walkHashes = make(map[string]*hashEntry)
+	}
+	now := sinkTime(sink).UTC()
+
+	err := filepath.WalkDir(root, func(filename string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, filename)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return nil
+		}
+		if rules.excluded(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !rules.hasExtension(rel) {
+			return nil
+		}
+
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		doc, err := htmlmeta.Parse(bytes.NewReader(content))
+		if err != nil {
+			return err
+		}
+		if doc.NoIndex {
+			return nil
+		}
+
+		u := &smg.SitemapLoc{Loc: rules.loc(rel), ChangeFreq: rules.ChangeFreq, Priority: rules.Priority}
+		switch rules.LastMod {
+		case ModTime:
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			modTime := info.ModTime().UTC()
+			u.LastMod = &modTime
+		case ContentHash:
+			sum := sha256.Sum256(content)
+			entry := &hashEntry{Hash: hex.EncodeToString(sum[:]), LastMod: now}
+			if previous, ok := hashes[rel]; ok && previous.Hash == entry.Hash {
+				entry.LastMod = previous.LastMod
+			}
+			walkHashes[rel] = entry
+			lastMod := entry.LastMod
+			u.LastMod = &lastMod
+		}
+		err = sink.Add(u)
+		if err != nil {
+			return fmt.Errorf("%s: %w", rel, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if rules.LastMod == ContentHash {
+		return writeHashFile(rules.HashFile, walkHashes)
+	}
+	return nil
+}
+
+// SaveDir walks the directory of a built static site into a new Sitemap of
+// the SitemapIndex and saves the SitemapIndex. returns the filename of SitemapIndex.
+func SaveDir(root string, rules DirRules, smi *smg.SitemapIndex) (string, error) {
+	err := WalkDir(root, rules, smi.NewSitemap())
+	if err != nil {
+		return "", err
+	}
+	return smi.Save()
+}
+
+// excluded checks whether the slash separated relative path matches any exclude pattern.
+func (r *DirRules) excluded(rel string) bool {
+	for _, pattern := range r.Exclude {
+		if matched, _ := path.Match(pattern, rel); matched {
+			return true
+		}
+		if !strings.Contains(pattern, "/") {
+			if matched, _ := path.Match(pattern, path.Base(rel)); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *DirRules) hasExtension(rel string) bool {
+	ext := path.Ext(rel)
+	for _, e := range r.Extensions {
+		if strings.EqualFold(e, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// loc maps the relative path of a file to its escaped root-relative URL.
+func (r *DirRules) loc(rel string) string {
+	dir, base := path.Split(rel)
+	for _, index := range r.IndexFiles {
+		if base == index {
+			return (&url.URL{Path: "/" + dir}).EscapedPath()
+		}
+	}
+	if r.StripExtensions {
+		rel = strings.TrimSuffix(rel, path.Ext(rel))
+	}
+	return (&url.URL{Path: "/" + rel}).EscapedPath()
+}
+
+func readHashFile(filename string) (map[string]*hashEntry, error) {
+	hashes := make(map[string]*hashEntry)
+	content, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return hashes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(content, &hashes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hash file %s: %w", filename, err)
+	}
+	return hashes, nil
+}
+
+func writeHashFile(filename string, hashes map[string]*hashEntry) error {
+	content, err := json.MarshalIndent(hashes, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(content, '\n'), 0666)
+}
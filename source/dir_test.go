@@ -0,0 +1,153 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sabloger/sitemap-generator/smg"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeSiteFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		filename := filepath.Join(root, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(filename), 0755)
+		if err == nil {
+			err = os.WriteFile(filename, []byte(content), 0666)
+		}
+		if err != nil {
+			t.Fatal("Unable to write site file:", err)
+		}
+	}
+}
+
+// TestWalkDir tests mapping the files of a static site to URLs with index, extension and exclude rules
+func TestWalkDir(t *testing.T) {
+	root := t.TempDir()
+	writeSiteFiles(t, root, map[string]string{
+		"index.html":             "<h1>Home</h1>",
+		"about.html":             "<h1>About</h1>",
+		"blog/index.html":        "<h1>Blog</h1>",
+		"blog/my post.html":      "<h1>Post</h1>",
+		"blog/draft.html":        `<meta name="robots" content="noindex,follow">`,
+		"drafts/index.html":      "<h1>Drafts</h1>",
+		"404.html":               "<h1>Not found</h1>",
+		"css/style.css":          "body {}",
+		"tags/go/index.html":     "<h1>Go</h1>",
+		"tags/go/page/2/a.html":  "<h1>Page 2</h1>",
+		"tags/go/page/2/b.XHTML": "<h1>XHTML</h1>",
+	})
+	modTime := time.Date(2022, 2, 12, 10, 0, 0, 0, time.UTC)
+	err := os.Chtimes(filepath.Join(root, "about.html"), modTime, modTime)
+	if err != nil {
+		t.Fatal("Unable to change file time:", err)
+	}
+
+	rules := DefaultDirRules
+	rules.Exclude = []string{"drafts", "404.html", "tags/*/page"}
+	sink := &sliceSink{}
+	err = WalkDir(root, rules, sink)
+	if err != nil {
+		t.Fatal("Unable to walk dir:", err)
+	}
+	locs := make([]string, len(sink.locs))
+	for i, u := range sink.locs {
+		locs[i] = u.Loc
+	}
+	assert.Equal(t, []string{"/about", "/blog/", "/blog/my%20post", "/", "/tags/go/"}, locs)
+	assert.Equal(t, modTime, *sink.locs[0].LastMod)
+
+	rules = DefaultDirRules
+	rules.StripExtensions = false
+	rules.IndexFiles = nil
+	rules.Exclude = []string{"[invalid"}
+	err = WalkDir(root, rules, sink)
+	assert.Error(t, err)
+}
+
+// TestWalkDirContentHash tests keeping the lastmod of unchanged content between walks
+func TestWalkDirContentHash(t *testing.T) {
+	root := t.TempDir()
+	writeSiteFiles(t, root, map[string]string{
+		"a.html": "<h1>A</h1>",
+		"b.html": "<h1>B</h1>",
+	})
+	rules := DefaultDirRules
+	rules.LastMod = ContentHash
+	rules.HashFile = filepath.Join(t.TempDir(), "hashes.json")
+
+	first := &sliceSink{}
+	err := WalkDir(root, rules, first)
+	if err != nil {
+		t.Fatal("Unable to walk dir:", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	writeSiteFiles(t, root, map[string]string{"b.html": "<h1>B changed</h1>"})
+
+	second := &sliceSink{}
+	err = WalkDir(root, rules, second)
+	if err != nil {
+		t.Fatal("Unable to walk dir:", err)
+	}
+	assert.Equal(t, *first.locs[0].LastMod, *second.locs[0].LastMod)
+	assert.True(t, second.locs[1].LastMod.After(*first.locs[1].LastMod))
+
+	rules.HashFile = ""
+	assert.Error(t, WalkDir(root, rules, second))
+}
+
+// TestWalkDirContentHashClock tests that the new content hashes take the time of the clock of Sitemap
+func TestWalkDirContentHashClock(t *testing.T) {
+	root := t.TempDir()
+	writeSiteFiles(t, root, map[string]string{"a.html": "<h1>A</h1>"})
+	rules := DefaultDirRules
+	rules.LastMod = ContentHash
+	rules.HashFile = filepath.Join(t.TempDir(), "hashes.json")
+
+	storage := smg.NewMemoryStorage()
+	sm := smg.NewSitemap(false)
+	sm.SetHostname("https://www.example.com")
+	sm.SetStorage(storage)
+	sm.SetCompress(false)
+	sm.SetClock(smg.FixedClock(time.Date(2022, 2, 12, 10, 0, 0, 0, time.UTC)))
+	err := WalkDir(root, rules, sm)
+	if err != nil {
+		t.Fatal("Unable to walk dir:", err)
+	}
+	_, err = sm.Save()
+	if err != nil {
+		t.Fatal("Unable to Save Sitemap:", err)
+	}
+	content, err := storage.ReadFile("sitemap.xml")
+	if err != nil {
+		t.Fatal("Unable to read sitemap:", err)
+	}
+	assert.Contains(t, string(content), "<lastmod>2022-02-12T10:00:00Z</lastmod>")
+}
+
+// TestSaveDir tests saving the sitemap index of a static site directory
+func TestSaveDir(t *testing.T) {
+	root := t.TempDir()
+	output := t.TempDir()
+	writeSiteFiles(t, root, map[string]string{"index.html": "<h1>Home</h1>", "about.html": "<h1>About</h1>"})
+
+	smi := smg.NewSitemapIndex(false)
+	smi.SetHostname("https://www.example.com")
+	smi.SetOutputPath(output)
+	smi.SetCompress(false)
+	filename, err := SaveDir(root, DefaultDirRules, smi)
+	if err != nil {
+		t.Fatal("Unable to save dir:", err)
+	}
+	assert.Equal(t, "sitemap.xml", filename)
+
+	content, err := os.ReadFile(filepath.Join(output, "sitemap1.xml"))
+	if err != nil {
+		t.Fatal("Unable to read sitemap:", err)
+	}
+	assert.True(t, strings.Contains(string(content), "<loc>https://www.example.com/about</loc>"))
+	assert.True(t, strings.Contains(string(content), "<loc>https://www.example.com/</loc>"))
+}
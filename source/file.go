@@ -0,0 +1,273 @@
+package source
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sabloger/sitemap-generator/smg"
+)
+
+// FileFormat is used for defining the format of data files.
+type FileFormat int
+
+// predefined FileFormat values
+const (
+	// CSV is comma separated values with a header row.
+	CSV FileFormat = iota
+	// TSV is tab separated values with a header row.
+	TSV
+	// JSONLines is one JSON object per line.
+	JSONLines
+	// Text is one URL per line. Blank lines and lines starting with # are ignored.
+	Text
+)
+
+const defaultImagesSeparator string = "|"
+
+// Columns maps the columns of CSV and TSV header or keys of JSON Lines objects
+// to the properties of SitemapLoc. Empty columns are not read except Loc which
+// is required. ImagesSeparator separates the image URLs in CSV and TSV columns
+// while JSON Lines images can be either an array or a separated string.
+// DefaultChangeFreq and DefaultPriority are set for the entries whose changefreq
+// or priority is empty, so an explicit priority of 0 is kept.
+type Columns struct {
+	Loc               string
+	LastMod           string
+	ChangeFreq        string
+	Priority          string
+	Images            string
+	ImagesSeparator   string
+	DefaultChangeFreq smg.ChangeFreq
+	DefaultPriority   float32
+}
+
+// DefaultColumns is the default mapping which uses the sitemap tag names as columns.
+var DefaultColumns = Columns{
+	Loc:             "loc",
+	LastMod:         "lastmod",
+	ChangeFreq:      "changefreq",
+	Priority:        "priority",
+	Images:          "images",
+	ImagesSeparator: defaultImagesSeparator,
+}
+
+// ParseFileFormat parses the name of a FileFormat which is one of
+// csv, tsv, jsonl or text.
+func ParseFileFormat(name string) (FileFormat, error) {
+	switch strings.ToLower(name) {
+	case "csv":
+		return CSV, nil
+	case "tsv":
+		return TSV, nil
+	case "jsonl", "ndjson", "jsonlines":
+		return JSONLines, nil
+	case "text", "txt":
+		return Text, nil
+	}
+	return 0, fmt.Errorf("unknown file format %q", name)
+}
+
+// FileFormatOf detects the FileFormat of filename by its extension.
+func FileFormatOf(filename string) (FileFormat, error) {
+	ext := strings.TrimPrefix(filepath.Ext(filename), ".")
+	if ext == "" {
+		return 0, fmt.Errorf("unable to detect file format of %q", filename)
+	}
+	return ParseFileFormat(ext)
+}
+
+// ReadFile reads the URLs of r in the format using the columns mapping
+// and adds them into the sink. errors contain the line number of the data.
+func ReadFile(r io.Reader, format FileFormat, columns Columns, sink Sink) error {
+	if columns.ImagesSeparator == "" {
+		columns.ImagesSeparator = defaultImagesSeparator
+	}
+	switch format {
+	case CSV:
+		return readCSV(r, ',', columns, sink)
+	case TSV:
+		return readCSV(r, '\t', columns, sink)
+	case JSONLines:
+		return readJSONLines(r, columns, sink)
+	case Text:
+		return readText(r, columns, sink)
+	}
+	return fmt.Errorf("unknown file format %d", format)
+}
+
+func readCSV(r io.Reader, comma rune, columns Columns, sink Sink) error {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	reader.LazyQuotes = comma == '\t'
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	indexes := make(map[string]int, len(header))
+	for i, name := range header {
+		indexes[strings.TrimSpace(name)] = i
+	}
+	if _, ok := indexes[columns.Loc]; !ok {
+		return fmt.Errorf("loc column %q does not exist in header", columns.Loc)
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		value := func(column string) string {
+			i, ok := indexes[column]
+			if column == "" || !ok || i >= len(record) {
+				return ""
+			}
+			return record[i]
+		}
+		var images []string
+		if raw := value(columns.Images); raw != "" {
+			images = strings.Split(raw, columns.ImagesSeparator)
+		}
+		u, err := columns.buildLoc(value(columns.Loc), value(columns.LastMod), value(columns.ChangeFreq), value(columns.Priority), images)
+		if err == nil {
+			err = sink.Add(u)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+func readJSONLines(r io.Reader, columns Columns, sink Sink) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var object map[string]interface{}
+		err := json.Unmarshal([]byte(text), &object)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		images, err := jsonImages(object[columns.Images], columns.ImagesSeparator)
+		if err == nil {
+			var u *smg.SitemapLoc
+			u, err = columns.buildLoc(jsonString(object, columns.Loc), jsonString(object, columns.LastMod),
+				jsonString(object, columns.ChangeFreq), jsonString(object, columns.Priority), images)
+			if err == nil {
+				err = sink.Add(u)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func readText(r io.Reader, columns Columns, sink Sink) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		u, err := columns.buildLoc(text, "", "", "", nil)
+		if err == nil {
+			err = sink.Add(u)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// buildLoc parses the raw values and builds a SitemapLoc. The defaults of columns
+// are used in case of empty changefreq and priority values.
+func (c *Columns) buildLoc(loc, lastMod, changeFreq, priority string, images []string) (*smg.SitemapLoc, error) {
+	loc = strings.TrimSpace(loc)
+	if loc == "" {
+		return nil, errors.New("loc is empty")
+	}
+	u := &smg.SitemapLoc{Loc: loc}
+
+	var err error
+	u.LastMod, err = ParseLastMod(lastMod)
+	if err != nil {
+		return nil, err
+	}
+	u.ChangeFreq, err = ParseChangeFreq(changeFreq)
+	if err != nil {
+		return nil, err
+	}
+	if u.ChangeFreq == "" {
+		u.ChangeFreq = c.DefaultChangeFreq
+	}
+	if strings.TrimSpace(priority) == "" {
+		u.Priority = c.DefaultPriority
+	} else {
+		u.Priority, err = ParsePriority(priority)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, image := range images {
+		if image = strings.TrimSpace(image); image != "" {
+			u.Images = append(u.Images, &smg.SitemapImage{ImageLoc: image})
+		}
+	}
+	return u, nil
+}
+
+// jsonString returns the value of key in object as string.
+func jsonString(object map[string]interface{}, key string) string {
+	if key == "" {
+		return ""
+	}
+	switch value := object[key].(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// jsonImages returns the image URLs of a JSON array or a separated string.
+func jsonImages(value interface{}, separator string) ([]string, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return strings.Split(value, separator), nil
+	case []interface{}:
+		images := make([]string, 0, len(value))
+		for _, image := range value {
+			imageLoc, ok := image.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid image %v", image)
+			}
+			images = append(images, imageLoc)
+		}
+		return images, nil
+	}
+	return nil, fmt.Errorf("invalid images %v", value)
+}
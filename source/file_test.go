@@ -0,0 +1,86 @@
+package source
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sabloger/sitemap-generator/smg"
+	"github.com/stretchr/testify/assert"
+)
+
+type sliceSink struct {
+	locs []*smg.SitemapLoc
+}
+
+func (s *sliceSink) Add(u *smg.SitemapLoc) error {
+	s.locs = append(s.locs, u)
+	return nil
+}
+
+// TestReadCSV tests reading CSV data with a custom columns mapping
+func TestReadCSV(t *testing.T) {
+	data := "url,updated_at,freq,prio,pictures\n" +
+		"/a,2022-02-12,daily,0.5,/a.jpg|/b.jpg\n" +
+		"/b,,,,\n"
+	columns := Columns{Loc: "url", LastMod: "updated_at", ChangeFreq: "freq", Priority: "prio", Images: "pictures"}
+
+	sink := &sliceSink{}
+	err := ReadFile(strings.NewReader(data), CSV, columns, sink)
+	if err != nil {
+		t.Fatal("Unable to read CSV:", err)
+	}
+	assert.Len(t, sink.locs, 2)
+	assert.Equal(t, "/a", sink.locs[0].Loc)
+	assert.Equal(t, time.Date(2022, 2, 12, 0, 0, 0, 0, time.UTC), *sink.locs[0].LastMod)
+	assert.Equal(t, smg.Daily, sink.locs[0].ChangeFreq)
+	assert.Equal(t, float32(0.5), sink.locs[0].Priority)
+	assert.Equal(t, []*smg.SitemapImage{{ImageLoc: "/a.jpg"}, {ImageLoc: "/b.jpg"}}, sink.locs[0].Images)
+	assert.Nil(t, sink.locs[1].LastMod)
+
+	err = ReadFile(strings.NewReader("url,freq\n/a,sometimes\n"), CSV, columns, &sliceSink{})
+	assert.EqualError(t, err, `line 2: invalid changefreq "sometimes"`)
+	err = ReadFile(strings.NewReader("loc\n/a\n"), CSV, columns, &sliceSink{})
+	assert.Error(t, err)
+}
+
+// TestReadTSVAndJSONLines tests reading TSV and JSON Lines data using the default columns
+func TestReadTSVAndJSONLines(t *testing.T) {
+	sink := &sliceSink{}
+	err := ReadFile(strings.NewReader("loc\tpriority\n/a\t1\n"), TSV, DefaultColumns, sink)
+	if err != nil {
+		t.Fatal("Unable to read TSV:", err)
+	}
+	data := `{"loc": "/b", "lastmod": "2022-02-12T16:29:46Z", "priority": 0.8, "images": ["/b.jpg"]}` + "\n\n" +
+		`{"loc": "/c", "changefreq": "Weekly", "images": "/c.jpg|/d.jpg"}` + "\n"
+	err = ReadFile(strings.NewReader(data), JSONLines, DefaultColumns, sink)
+	if err != nil {
+		t.Fatal("Unable to read JSON Lines:", err)
+	}
+	assert.Len(t, sink.locs, 3)
+	assert.Equal(t, float32(1), sink.locs[0].Priority)
+	assert.Equal(t, float32(0.8), sink.locs[1].Priority)
+	assert.Equal(t, "/b.jpg", sink.locs[1].Images[0].ImageLoc)
+	assert.Equal(t, smg.Weekly, sink.locs[2].ChangeFreq)
+	assert.Len(t, sink.locs[2].Images, 2)
+
+	err = ReadFile(strings.NewReader(`{"lastmod": "yesterday", "loc": "/a"}`), JSONLines, DefaultColumns, sink)
+	assert.EqualError(t, err, `line 1: invalid lastmod "yesterday"`)
+}
+
+// TestReadText tests reading text data which has one URL per line
+func TestReadText(t *testing.T) {
+	sink := &sliceSink{}
+	err := ReadFile(strings.NewReader("# comment\n/a\n\n  /b  \n"), Text, DefaultColumns, sink)
+	if err != nil {
+		t.Fatal("Unable to read text:", err)
+	}
+	assert.Len(t, sink.locs, 2)
+	assert.Equal(t, "/b", sink.locs[1].Loc)
+
+	format, err := FileFormatOf("export.jsonl")
+	assert.NoError(t, err)
+	assert.Equal(t, JSONLines, format)
+	_, err = FileFormatOf("export")
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+// Package source provides the adapters which read URLs from different sources
+// and feed them as SitemapLoc items into a Sitemap of smg package.
+package source
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sabloger/sitemap-generator/smg"
+)
+
+// Sink is the destination of SitemapLoc items which is implemented by smg.Sitemap.
+type Sink interface {
+	Add(u *smg.SitemapLoc) error
+}
+
+// clock is implemented by the Sinks which have a clock, e.g. smg.Sitemap and it's SetClock.
+type clock interface {
+	Now() time.Time
+}
+
+// sinkTime returns the current time of the clock of sink or time.Now in case of absence.
+func sinkTime(sink Sink) time.Time {
+	if c, ok := sink.(clock); ok {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+// lastModLayouts are the accepted layouts of lastmod values.
+var lastModLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// ParseLastMod parses a lastmod value in W3C Datetime formats or "2006-01-02 15:04:05".
+// returns nil in case of empty value.
+func ParseLastMod(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range lastModLayouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid lastmod %q", value)
+}
+
+// ParseChangeFreq parses and validates a changefreq value.
+func ParseChangeFreq(value string) (smg.ChangeFreq, error) {
+	changeFreq := smg.ChangeFreq(strings.ToLower(strings.TrimSpace(value)))
+	switch changeFreq {
+	case "", smg.Always, smg.Hourly, smg.Daily, smg.Weekly, smg.Monthly, smg.Yearly, smg.Never:
+		return changeFreq, nil
+	}
+	return "", fmt.Errorf("invalid changefreq %q", value)
+}
+
+// ParsePriority parses and validates a priority value which must be between 0.0 and 1.0.
+func ParsePriority(value string) (float32, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	priority, err := strconv.ParseFloat(value, 32)
+	if err != nil || priority < 0 || priority > 1 {
+		return 0, fmt.Errorf("invalid priority %q", value)
+	}
+	return float32(priority), nil
+}
@@ -0,0 +1,179 @@
+package source
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sabloger/sitemap-generator/smg"
+)
+
+const defaultSQLPageSize int = 10000
+
+// invalidNameChars matches the characters which are replaced in the sitemap names of shards.
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// SQLQuery describes the query of ReadSQL which is paginated using keyset pagination.
+// Query is a SELECT statement which is wrapped as a subquery for fetching the pages ordered by
+// KeyColumn which must be unique and orderable. Args are the arguments of Query placeholders.
+// Placeholder is the placeholder of the last key argument which is "?" by default and may
+// contain %d for the position of argument like "$%d" for PostgreSQL.
+// PageSize is the # of rows in each page. Default is 10000.
+// ShardColumn is the column which its value names the sitemap of rows in ReadSQLShards.
+type SQLQuery struct {
+	Query       string
+	Args        []interface{}
+	KeyColumn   string
+	ShardColumn string
+	PageSize    int
+	Placeholder string
+}
+
+// ReadSQL streams the rows of query in pages and adds them into the sink using the columns mapping.
+// Pages are fetched by the last value of KeyColumn instead of OFFSET which keeps the cost of
+// each page constant on large tables. errors contain the key value of the row.
+func ReadSQL(ctx context.Context, db *sql.DB, query SQLQuery, columns Columns, sink Sink) error {
+	return readSQL(ctx, db, query, columns, func(_ string, u *smg.SitemapLoc) error {
+		return sink.Add(u)
+	})
+}
+
+// ReadSQLShards streams the rows of query like ReadSQL and adds them into the SitemapIndex
+// using AddURLToShard, so the Sitemaps are named by the value of ShardColumn of rows.
+// The invalid characters of names are replaced by "_". The rows are routed by the
+// shard strategy of SitemapIndex in case of absence of ShardColumn or its value.
+func ReadSQLShards(ctx context.Context, db *sql.DB, query SQLQuery, columns Columns, smi *smg.SitemapIndex) error {
+	return readSQL(ctx, db, query, columns, func(shard string, u *smg.SitemapLoc) error {
+		return smi.AddURLToShard(invalidNameChars.ReplaceAllString(shard, "_"), u)
+	})
+}
+
+func readSQL(ctx context.Context, db *sql.DB, query SQLQuery, columns Columns, add func(shard string, u *smg.SitemapLoc) error) error {
+	if query.KeyColumn == "" {
+		return errors.New("KeyColumn is required for keyset pagination")
+	}
+	if columns.ImagesSeparator == "" {
+		columns.ImagesSeparator = defaultImagesSeparator
+	}
+	pageSize := query.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultSQLPageSize
+	}
+
+	var lastKey interface{}
+	for first := true; ; first = false {
+		statement, args := query.page(first, lastKey, pageSize)
+		count, key, err := readSQLPage(ctx, db, statement, args, query, columns, add)
+		if err != nil {
+			return err
+		}
+		if count < pageSize {
+			return nil
+		}
+		lastKey = key
+	}
+}
+
+// page returns the statement and arguments of the page after lastKey.
+func (q *SQLQuery) page(first bool, lastKey interface{}, pageSize int) (string, []interface{}) {
+	args := make([]interface{}, len(q.Args), len(q.Args)+1)
+	copy(args, q.Args)
+	where := ""
+	if !first {
+		placeholder := q.Placeholder
+		if placeholder == "" {
+			placeholder = "?"
+		}
+		if strings.Contains(placeholder, "%d") {
+			placeholder = fmt.Sprintf(placeholder, len(args)+1)
+		}
+		where = fmt.Sprintf(" WHERE smg_page.%s > %s", q.KeyColumn, placeholder)
+		args = append(args, lastKey)
+	}
+	statement := fmt.Sprintf("SELECT * FROM (%s) smg_page%s ORDER BY smg_page.%s LIMIT %d",
+		strings.TrimRight(strings.TrimSpace(q.Query), ";"), where, q.KeyColumn, pageSize)
+	return statement, args
+}
+
+// readSQLPage reads the rows of a page and returns their count and the key of the last row.
+func readSQLPage(ctx context.Context, db *sql.DB, statement string, args []interface{}, query SQLQuery, columns Columns,
+	add func(shard string, u *smg.SitemapLoc) error) (count int, key interface{}, err error) {
+	rows, err := db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return 0, nil, err
+	}
+	indexes := make(map[string]int, len(names))
+	for i, name := range names {
+		indexes[name] = i
+	}
+	for _, column := range []string{query.KeyColumn, columns.Loc, query.ShardColumn} {
+		if _, ok := indexes[column]; column != "" && !ok {
+			return 0, nil, fmt.Errorf("column %q does not exist in query result", column)
+		}
+	}
+
+	values := make([]interface{}, len(names))
+	dest := make([]interface{}, len(names))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		err = rows.Scan(dest...)
+		if err != nil {
+			return 0, nil, err
+		}
+		count++
+		key = values[indexes[query.KeyColumn]]
+		value := func(column string) string {
+			i, ok := indexes[column]
+			if column == "" || !ok {
+				return ""
+			}
+			return sqlString(values[i])
+		}
+
+		var images []string
+		if raw := value(columns.Images); raw != "" {
+			images = strings.Split(raw, columns.ImagesSeparator)
+		}
+		u, err := columns.buildLoc(value(columns.Loc), value(columns.LastMod), value(columns.ChangeFreq), value(columns.Priority), images)
+		if err == nil {
+			err = add(value(query.ShardColumn), u)
+		}
+		if err != nil {
+			return 0, nil, fmt.Errorf("row %s: %w", sqlString(key), err)
+		}
+	}
+	return count, key, rows.Err()
+}
+
+// sqlString converts a scanned value into its string form.
+// time values are formatted in RFC3339 format with nanoseconds.
+func sqlString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,197 @@
+package source
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sabloger/sitemap-generator/smg"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubDriver is an in-process SQL driver which serves the pages of stubRows
+// based on the key argument and LIMIT of the keyset pagination queries.
+type stubDriver struct {
+	queries []string
+}
+
+type stubConn struct{ driver *stubDriver }
+
+type stubStmt struct {
+	driver *stubDriver
+	query  string
+}
+
+type stubRows struct {
+	rows [][]driver.Value
+	i    int
+}
+
+var (
+	stubColumns = []string{"id", "url", "updated_at", "section"}
+	stubData    = [][]driver.Value{
+		{int64(1), "/a", time.Date(2022, 2, 12, 0, 0, 0, 0, time.UTC), "blog"},
+		{int64(2), "/b", nil, "news"},
+		{int64(3), "/c", "2022-02-13", "blog"},
+		{int64(4), "/d", []byte("2022-02-14 10:00:00"), "news/world"},
+		{int64(5), "/e", nil, "blog"},
+	}
+	stubDriverInstance = &stubDriver{}
+	limitPattern       = regexp.MustCompile(`LIMIT (\d+)$`)
+)
+
+func init() {
+	sql.Register("smg-stub", stubDriverInstance)
+}
+
+func (d *stubDriver) Open(string) (driver.Conn, error) { return &stubConn{driver: d}, nil }
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) {
+	return &stubStmt{driver: c.driver, query: query}, nil
+}
+func (c *stubConn) Close() error              { return nil }
+func (c *stubConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (s *stubStmt) Close() error  { return nil }
+func (s *stubStmt) NumInput() int { return -1 }
+func (s *stubStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *stubStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.driver.queries = append(s.driver.queries, s.query)
+	limit, err := strconv.Atoi(limitPattern.FindStringSubmatch(s.query)[1])
+	if err != nil {
+		return nil, err
+	}
+	var after int64
+	if strings.Contains(s.query, "smg_page.id >") {
+		after = args[len(args)-1].(int64)
+	}
+	rows := &stubRows{}
+	for _, row := range stubData {
+		if row[0].(int64) > after && len(rows.rows) < limit {
+			rows.rows = append(rows.rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func (r *stubRows) Columns() []string { return stubColumns }
+func (r *stubRows) Close() error      { return nil }
+func (r *stubRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.i])
+	r.i++
+	return nil
+}
+
+// TestReadSQL tests reading rows with keyset pagination
+func TestReadSQL(t *testing.T) {
+	db, err := sql.Open("smg-stub", "")
+	if err != nil {
+		t.Fatal("Unable to open db:", err)
+	}
+	defer db.Close()
+	stubDriverInstance.queries = nil
+
+	query := SQLQuery{
+		Query:       "SELECT id, url, updated_at, section FROM pages WHERE published = $1;",
+		Args:        []interface{}{true},
+		KeyColumn:   "id",
+		PageSize:    2,
+		Placeholder: "$%d",
+	}
+	columns := Columns{Loc: "url", LastMod: "updated_at"}
+	sink := &sliceSink{}
+	err = ReadSQL(context.Background(), db, query, columns, sink)
+	if err != nil {
+		t.Fatal("Unable to read SQL:", err)
+	}
+
+	assert.Len(t, sink.locs, 5)
+	assert.Equal(t, "/a", sink.locs[0].Loc)
+	assert.Equal(t, time.Date(2022, 2, 12, 0, 0, 0, 0, time.UTC), *sink.locs[0].LastMod)
+	assert.Nil(t, sink.locs[1].LastMod)
+	assert.Equal(t, time.Date(2022, 2, 14, 10, 0, 0, 0, time.UTC), *sink.locs[3].LastMod)
+	assert.Equal(t, []string{
+		"SELECT * FROM (SELECT id, url, updated_at, section FROM pages WHERE published = $1) smg_page ORDER BY smg_page.id LIMIT 2",
+		"SELECT * FROM (SELECT id, url, updated_at, section FROM pages WHERE published = $1) smg_page WHERE smg_page.id > $2 ORDER BY smg_page.id LIMIT 2",
+		"SELECT * FROM (SELECT id, url, updated_at, section FROM pages WHERE published = $1) smg_page WHERE smg_page.id > $2 ORDER BY smg_page.id LIMIT 2",
+	}, stubDriverInstance.queries)
+
+	query.KeyColumn = "missing"
+	err = ReadSQL(context.Background(), db, query, columns, sink)
+	assert.Error(t, err)
+}
+
+// TestReadSQLShards tests sharding the rows into named sitemaps of a SitemapIndex by a column value
+func TestReadSQLShards(t *testing.T) {
+	db, err := sql.Open("smg-stub", "")
+	if err != nil {
+		t.Fatal("Unable to open db:", err)
+	}
+	defer db.Close()
+
+	output := t.TempDir()
+	smi := smg.NewSitemapIndex(false)
+	smi.SetHostname("https://www.example.com")
+	smi.SetOutputPath(output)
+	smi.SetCompress(false)
+	query := SQLQuery{
+		Query:       "SELECT * FROM pages",
+		KeyColumn:   "id",
+		ShardColumn: "section",
+	}
+	err = ReadSQLShards(context.Background(), db, query, Columns{Loc: "url"}, smi)
+	if err != nil {
+		t.Fatal("Unable to read SQL:", err)
+	}
+	_, err = smi.Save()
+	if err != nil {
+		t.Fatal("Unable to save:", err)
+	}
+
+	for filename, count := range map[string]int{"blog.xml": 3, "news.xml": 1, "news_world.xml": 1} {
+		content, err := os.ReadFile(filepath.Join(output, filename))
+		if err != nil {
+			t.Fatal("Unable to read sitemap:", err)
+		}
+		assert.Equal(t, count, strings.Count(string(content), "<url>"), filename)
+	}
+
+	// the shard strategy of SitemapIndex routes the rows without ShardColumn
+	output = t.TempDir()
+	smi = smg.NewSitemapIndex(false)
+	smi.SetHostname("https://www.example.com")
+	smi.SetOutputPath(output)
+	smi.SetCompress(false)
+	smi.SetShardStrategy(smg.ShardByPathPrefix(map[string]string{"/a": "first"}, "rest"))
+	query.ShardColumn = ""
+	err = ReadSQLShards(context.Background(), db, query, Columns{Loc: "url"}, smi)
+	if err != nil {
+		t.Fatal("Unable to read SQL:", err)
+	}
+	_, err = smi.Save()
+	if err != nil {
+		t.Fatal("Unable to save:", err)
+	}
+	for filename, count := range map[string]int{"first.xml": 1, "rest.xml": 4} {
+		content, err := os.ReadFile(filepath.Join(output, filename))
+		if err != nil {
+			t.Fatal("Unable to read sitemap:", err)
+		}
+		assert.Equal(t, count, strings.Count(string(content), "<url>"), filename)
+	}
+}